@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"log"
 	"fmt"
+	"os"
 	"path"
 
 	"github.com/forj-oss/goforjj"
@@ -58,7 +59,7 @@ func DoCreate(r *http.Request, req *CreateReq, ret *goforjj.PluginData) (httpCod
 	log.Println("Checking gitlab connection.")
 	ret.StatusAdd("Connect to gitlab...")
 
-	if git := gls.gitlabConnect("X"/*"myserv"*/, ret); git == nil{
+	if git := gls.gitlabConnect(gls.app.Server, ret); git == nil{
 		return
 	}
 
@@ -73,9 +74,18 @@ func DoCreate(r *http.Request, req *CreateReq, ret *goforjj.PluginData) (httpCod
 		return
 	}
 
+	//Import mode: existing projects are adopted
+	importing := (gls.app.Import == "true")
+	if importing {
+		if err := gls.importProjects(ret); err != nil {
+			ret.Errorf("Unable to import. %s", err)
+			return
+		}
+	}
+
 	//repos exist ?
-	if err := gls.projectsExists(ret); err != nil {
-		ret.Errorf("%s\nUnable to 'create' your forge when gitlab already has an infra project created. Clone it and use 'update' instead.", err)
+	if err := gls.projectsExists(ret); err != nil && !importing {
+		ret.Errorf("%s\nUnable to 'create' your forge when gitlab already has an infra project created. Clone it and use 'update' instead. Or use the app import flag to adopt existing projects.", err)
 		return 419
 	}
 
@@ -163,9 +173,14 @@ func DoUpdate(r *http.Request, req *UpdateReq, ret *goforjj.PluginData) (httpCod
 		return
 	}
 
-	if err := gls.loadYaml(gls.sourceFile); err != nil {
-		ret.Errorf("Unable to update gitlab instance '%s' source files. %s. Use 'create' to create it first.", instance, err)
-		return 419
+	//Load current deploy data to detect removed projects
+	if _, err := os.Stat(gls.deployFile); err == nil {
+		if err := gls.loadYaml(gls.deployFile); err != nil {
+			ret.Errorf("Unable to update gitlab instance '%s' deploy files. %s.", instance, err)
+			return 419
+		}
+	} else {
+		log.Printf("'%s' not found. Deploy data will be created.", gls.deployFile)
 	}
 
 	if !req.InitGroup(&gls){
@@ -173,46 +188,60 @@ func DoUpdate(r *http.Request, req *UpdateReq, ret *goforjj.PluginData) (httpCod
 		return
 	}
 
-	if git := gls.gitlabConnect("X", ret); git == nil{
+	if git := gls.gitlabConnect(gls.app.Server, ret); git == nil{
 		return
 	}
 
 	ret.StatusAdd("Environment checked. Ready to be updated.")
 
-	if _, err := gls.updateYamlData(req, ret); err != nil{ //fct TODO
+	if err := gls.updateYamlData(req, ret); err != nil{
 		ret.Errorf("Unable to update. %s", err)
 		return
 	}
+	if ret.ErrorMessage != "" {
+		//invalid Forjfile objects: nothing saved
+		return
+	}
 
 	gls.projectsExists(ret)
 
-	if Updated, err := gls.saveYaml(&gls.gitlabSource, gls.sourceFile); err != nil {
-		ret.Errorf("%s", err)
+	deployUpdated, err := gls.deployChanged()
+	if err != nil{
+		ret.Errorf("Unable to update. %s", err)
 		return
-	} else {
-		if !Updated {
-			log.Printf(ret.StatusAdd("Source: No gitlab configuration update detected."))
-		} else {
-			log.Printf(ret.StatusAdd("Source: gitlab configuration saved in '%s'.", path.Join(instance, gitlabFile)))
-
-			ret.CommitMessage = fmt.Sprint("Source: gitlab configuration updated.")
-			ret.AddFile(goforjj.FilesSource, path.Join(instance, gitlabFile))
-		}
 	}
 
-	//Save gls.gitlabDeploy
-	if Updated, err := gls.saveYaml(&gls.gitlabDeploy, gls.deployFile); err != nil {
+	sourceUpdated, err := gls.saveYaml(&gls.gitlabSource, gls.sourceFile)
+	if err != nil {
 		ret.Errorf("%s", err)
 		return
+	}
+	if !sourceUpdated {
+		log.Printf(ret.StatusAdd("Source: No gitlab configuration update detected."))
 	} else {
-		if !Updated {
-			log.Printf(ret.StatusAdd("Deploy: No gitlab configuration update detected."))
-		} else {
-			log.Printf(ret.StatusAdd("Deploy: gitlab configuration seved in '%s'.", path.Join(instance, gitlabFile)))
+		log.Printf(ret.StatusAdd("Source: gitlab configuration saved in '%s'.", path.Join(instance, gitlabFile)))
+		ret.AddFile(goforjj.FilesSource, path.Join(instance, gitlabFile))
+	}
 
-			ret.CommitMessage = fmt.Sprint("Deploy: gitlab configuration updated.")
-			ret.AddFile(goforjj.FilesDeploy, path.Join(instance, gitlabFile))
+	//Save gls.gitlabDeploy
+	if !deployUpdated {
+		log.Printf(ret.StatusAdd("Deploy: No gitlab configuration update detected."))
+	} else {
+		if _, err := gls.saveYaml(&gls.gitlabDeploy, gls.deployFile); err != nil {
+			ret.Errorf("%s", err)
+			return
 		}
+		log.Printf(ret.StatusAdd("Deploy: gitlab configuration saved in '%s'.", path.Join(instance, gitlabFile)))
+		ret.AddFile(goforjj.FilesDeploy, path.Join(instance, gitlabFile))
+	}
+
+	switch {
+	case sourceUpdated && deployUpdated:
+		ret.CommitMessage = fmt.Sprint("Gitlab configuration updated.")
+	case sourceUpdated:
+		ret.CommitMessage = fmt.Sprint("Source: gitlab configuration updated.")
+	case deployUpdated:
+		ret.CommitMessage = fmt.Sprint("Deploy: gitlab configuration updated.")
 	}
 
 	//Building final post answer
@@ -230,23 +259,49 @@ func DoUpdate(r *http.Request, req *UpdateReq, ret *goforjj.PluginData) (httpCod
 // req_data contains the request data posted by forjj. Structure generated from 'gitlab.yaml'.
 // ret_data contains the response structure to return back to forjj.
 //
+// plan receives the planned changes in plan mode.
+//
 // By default, if httpCode is not set (ie equal to 0), the function caller will set it to 422 in case of errors (error_message != "") or 200
-func DoMaintain(r *http.Request, req *MaintainReq, ret *goforjj.PluginData) (httpCode int) {
+func DoMaintain(r *http.Request, req *MaintainReq, ret *goforjj.PluginData, plan *[]planChange) (httpCode int) {
+	gls := maintainPlugin(req, ret)
+	if gls == nil {
+		return
+	}
+
+	if gls.plan {
+		log.Printf(ret.StatusAdd("Plan mode: gitlab changes are reported, not applied."))
+		defer gls.planReport(ret, plan)
+	}
+
+	gls.maintain(ret)
+	gls.saveHookTokens(ret)
+	return
+}
+
+//maintainPlugin return the plugin loaded from the deploy file and connected to gitlab. nil on error.
+func maintainPlugin(req *MaintainReq, ret *goforjj.PluginData) *GitlabPlugin {
 	instance := req.Forj.ForjjInstanceName
 
 	var gls GitlabPlugin
 	if a, found := req.Objects.App[instance]; !found {
 		ret.Errorf("Invalid request. Missing Objects/App/%s", instance)
-		return
+		return nil
 	} else {
 		gls = GitlabPlugin{
 			deployMount: 		req.Forj.ForjjDeployMount,
+			instance: 			instance,
 			workspaceMount: 	req.Forj.ForjjWorkspaceMount,
 			token: 				a.Token,
 			maintainCtxt: 		true,
 			force: 				req.Forj.Force == "true",
+			plan: 				req.Forj.Plan == "true",
+			hookTokens: 		make(map[string]string),
 		}
 	}
+
+	for name, hook := range req.Objects.Webhooks {
+		gls.hookTokens[name] = hook.SecretToken
+	}
 	
 	check := make(map[string]bool)
 	check["token"] = true
@@ -254,21 +309,26 @@ func DoMaintain(r *http.Request, req *MaintainReq, ret *goforjj.PluginData) (htt
 	check["deploy"] = true
 
 	if gls.verifyReqFails(ret, check) {
-		return
+		return nil
 	}
 
 	confFile := path.Join(gls.deployMount, req.Forj.ForjjDeploymentEnv, instance, gitlabFile)
 
 	//read yaml file
 	if err := gls.loadYaml(confFile/*ret, instance*/); err != nil{
-		ret.Errorf("%s"/*, err*/)
-		return
+		ret.Errorf("%s", err)
+		return nil
 	}
 	
 	if gls.gitlabConnect("", ret) == nil{
-		return
+		return nil
 	}
+	return &gls
+}
 
+//maintain update gitlab group, users, webhooks, subgroups and projects from deploy data.
+// In plan mode, changes are only registered.
+func (gls *GitlabPlugin) maintain(ret *goforjj.PluginData) (_ bool) {
 	if !gls.ensureGroupExists(ret){
 		return
 	}
@@ -277,6 +337,21 @@ func DoMaintain(r *http.Request, req *MaintainReq, ret *goforjj.PluginData) (htt
 		return
 	}
 
+	if err := gls.ensureUsers(ret); err != nil{
+		return
+	}
+
+	if err := gls.ensureGroupHooks(ret); err != nil{
+		return
+	}
+
+	for name, groupData := range gls.gitlabDeploy.Groups{
+		if err := groupData.ensureExists(gls, ret); err != nil{
+			return
+		}
+		log.Printf(ret.StatusAdd("Group maintained: %s", name))
+	}
+
 	if gls.gitlabDeploy.NoProjects{
 		log.Printf(ret.StatusAdd("Projects maintained limited to your infra project"))
 	}
@@ -287,18 +362,25 @@ func DoMaintain(r *http.Request, req *MaintainReq, ret *goforjj.PluginData) (htt
 			log.Printf(ret.StatusAdd("Project ignored: %s", name))
 			continue
 		}
+		if projectData.Removed{
+			if err := projectData.ensureRemoved(gls, ret); err != nil{
+				return
+			}
+			continue
+		}
 		if projectData.Role == "infra" && !projectData.IsDeployable{
 			log.Printf(ret.StatusAdd("Project ignored: %s - Infra project owned by '%s'", name, gls.gitlabDeploy.ProdGroup))
 			continue
 		}
-		if err := projectData.ensureExists(&gls, ret); err != nil{
+		if err := projectData.ensureExists(gls, ret); err != nil{
 			return
 		}
+		ret.Repos[name] = projectData.pluginRepo()
 		
 		//...
 		log.Printf(ret.StatusAdd("Project maintained: %s", name))
 	}
 	
 
-	return
+	return true
 }
@@ -21,6 +21,17 @@ type Params struct {
 	socket_file *string
 	socket_path *string
 	daemon      *bool // Currently not used - Lot of concerns with daemonize in go... Stay in foreground
+
+	//export
+	server    *string
+	sshServer *string
+	token     *string
+	group     *string
+	output    *string
+
+	//drift
+	deployFile *string
+	json       *bool
 }
 
 func (a *GitlabApp) init() {
@@ -38,6 +49,18 @@ func (a *GitlabApp) init() {
 	a.params.socket_file = daemon.Flag("socket-file", "Socket file to use").Default(a.Yaml.Runtime.Service.Socket).String()
 	a.params.socket_path = daemon.Flag("socket-path", "Socket file path to use").Default("/tmp/forjj-socks").String()
 	a.params.daemon = daemon.Flag("daemon", "Start process in background like a daemon").Short('d').Bool()
+
+	export := a.App.Command("export", "export a gitlab group as Forjfile fragments and deploy data")
+	a.params.server = export.Flag("server", "Gitlab server ([scheme://]host[:port][/path])").Default(defaultServer).String()
+	a.params.sshServer = export.Flag("ssh-server", "Gitlab ssh server (host[:port]) if different from the server").String()
+	a.params.token = export.Flag("token", "Gitlab token").Envar("GITLAB_TOKEN").Required().String()
+	a.params.output = export.Flag("output", "Directory to write files to. Files are printed if not set").Short('o').String()
+	a.params.group = export.Arg("group", "Gitlab group path to export").Required().String()
+
+	drift := a.App.Command("drift", "report differences between gitlab and a deploy file. Exit with 2 on drift")
+	drift.Flag("token", "Gitlab token").Envar("GITLAB_TOKEN").Required().StringVar(a.params.token)
+	a.params.json = drift.Flag("json", "Print the report in json").Bool()
+	a.params.deployFile = drift.Arg("deploy-file", "Deploy file to compare ("+gitlabFile+")").Required().String()
 }
 
 func (a *GitlabApp) load_plugin_def() {
@@ -40,7 +40,14 @@ func (gls *GitlabPlugin) createYamlData(req *CreateReq, ret *goforjj.PluginData)
 	}
 
 	gls.gitlabDeploy.Projects = make(map[string]ProjectStruct)
-	//gls.gitlabDeploy.Users = ...
+	if err := gls.SetVisibility(); err != nil {
+		return err
+	}
+	if err := gls.SetRemovedProjectsPolicy(); err != nil {
+		return err
+	}
+	gls.SetUsers(req.Objects.User, ret)
+	gls.SetGroups(req.Objects.Group, ret)
 
 	//Norepo
 	gls.gitlabDeploy.NoProjects = (gls.app.ProjectsDisabled == "true")
@@ -48,7 +55,11 @@ func (gls *GitlabPlugin) createYamlData(req *CreateReq, ret *goforjj.PluginData)
 		log.Print("Repositories_disabled is true. forjj_gitlab won't manage repositories except the infra repository.")
 	}
 
-	//SetOrgHooks
+	gls.gitlabDeploy.NoProjectHooks = (gls.app.ProjectsWebhooksDisabled == "true")
+
+	if err := gls.SetGroupHooks(req.Objects.Webhooks, ret); err != nil {
+		return err
+	}
 
 	for name, project := range req.Objects.Repo{
 		isInfra := (name == gls.app.ForjjInfra)
@@ -60,7 +71,10 @@ func (gls *GitlabPlugin) createYamlData(req *CreateReq, ret *goforjj.PluginData)
 			continue
 		}
 		gls.SetProject(&project, isInfra, project.Deployable == "true")
-		//gls.SetHooks(...)
+		if err := gls.SetProjectHooks(name, &project, req.Objects.Webhooks, ret); err != nil {
+			return err
+		}
+		gls.SetProjectProtections(name, &project, req.Objects.Protections, ret)
 
 	}
 
@@ -73,8 +87,8 @@ func (gls *GitlabPlugin) createYamlData(req *CreateReq, ret *goforjj.PluginData)
 // DefineRepoUrls return default repo url for the repo name given
 func (gls *GitlabPlugin) DefineRepoUrls(name string) (upstream goforjj.PluginRepoRemoteUrl){
 	upstream = goforjj.PluginRepoRemoteUrl{
-		Ssh: gls.gitlabSource.Urls["gitlab-ssh"] + gls.gitlabDeploy.Group + "/" + name + ".git",
-		Url: gls.gitlabSource.Urls["gitlab-url"] + "/" + gls.gitlabDeploy.Group + "/" + name,
+		Ssh: gls.gitlabSource.Urls["gitlab-ssh"] + gls.projectPath(name) + ".git",
+		Url: gls.gitlabSource.Urls["gitlab-url"] + "/" + gls.projectPath(name),
 	}
 	return
 }
\ No newline at end of file
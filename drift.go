@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/forj-oss/goforjj"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+//driftExitCode exit code of the drift command when gitlab differs from the deploy file.
+const driftExitCode = 2
+
+//driftReport drift check answer. Changes are the ones maintain would apply.
+type driftReport struct {
+	*goforjj.PluginData
+	Drift   bool         `json:"drift"`
+	Changes []planChange `json:"changes"`
+}
+
+//driftText return the human readable difference.
+func driftText(c planChange) string {
+	if c.attributeChange == nil {
+		return c.String()
+	}
+	return fmt.Sprintf("%s '%s': %s is '%s' in gitlab, '%s' in the deploy file", c.Kind, c.Name, c.Attribute, c.Gitlab, c.Deploy)
+}
+
+//driftCheck compare gitlab with the deploy data loaded. maintain runs in plan mode, so nothing is changed in gitlab.
+func (gls *GitlabPlugin) driftCheck(ret *goforjj.PluginData) (report *driftReport) {
+	report = &driftReport{PluginData: ret, Changes: []planChange{}}
+
+	gls.plan = true
+	gls.planChanges = nil
+	if !gls.maintain(ret) {
+		return
+	}
+
+	report.Changes = append(report.Changes, gls.planChanges...)
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		a, b := report.Changes[i], report.Changes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.attributeChange != nil && b.attributeChange != nil && a.Attribute < b.Attribute
+	})
+
+	report.Drift = (len(report.Changes) > 0)
+	if !report.Drift {
+		log.Printf(ret.StatusAdd("Drift: No drift. Gitlab matches the deploy file."))
+		return
+	}
+	log.Printf(ret.StatusAdd("Drift: %d difference(s) found.", len(report.Changes)))
+	for _, change := range report.Changes {
+		log.Printf(ret.StatusAdd("Drift: %s", driftText(change)))
+	}
+	return
+}
+
+//drift compare gitlab with a deploy file and print the report. Exit with driftExitCode on drift.
+func (a *GitlabApp) drift() {
+	gls := GitlabPlugin{
+		token:        *a.params.token,
+		maintainCtxt: true,
+		hookTokens:   make(map[string]string),
+	}
+	ret := newPluginData()
+
+	if err := gls.loadYaml(*a.params.deployFile); err != nil {
+		kingpin.Fatalf("%s", err)
+	}
+	if gls.gitlabConnect("", ret) == nil {
+		kingpin.Fatalf("%s", ret.ErrorMessage)
+	}
+
+	report := gls.driftCheck(ret)
+	if ret.ErrorMessage != "" {
+		kingpin.Fatalf("Unable to check drift. %s", ret.ErrorMessage)
+	}
+
+	if *a.params.json {
+		if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			kingpin.Fatalf("Unable to encode the report in json. %s", err)
+		}
+	} else if report.Drift {
+		fmt.Printf("%d difference(s) between gitlab and '%s':\n", len(report.Changes), *a.params.deployFile)
+		for _, change := range report.Changes {
+			fmt.Printf("  %s\n", driftText(change))
+		}
+	} else {
+		fmt.Printf("No drift. Gitlab matches '%s'.\n", *a.params.deployFile)
+	}
+
+	if report.Drift {
+		os.Exit(driftExitCode)
+	}
+}
+
+// Drift handler. Like maintain, from the deploy file without changing gitlab.
+func Drift(w http.ResponseWriter, r *http.Request) {
+	var reqData MaintainReq
+	answer := &driftReport{PluginData: newPluginData(), Changes: []planChange{}}
+
+	answerPluginAction(w, r, answer.PluginData, answer,
+		func(body []byte) error {
+			return json.Unmarshal(body, &reqData)
+		},
+		func(data *goforjj.PluginData) (errCode int) {
+			if gls := maintainPlugin(&reqData, data); gls != nil {
+				*answer = *gls.driftCheck(data)
+			}
+			return
+		})
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+)
+
+const forjfileFragment = "Forjfile.gitlab.yaml"
+
+//forjfileEntries Forjfile objects by name (repositories, groups, users, webhooks, protections)
+type forjfileEntries map[string]map[string]interface{}
+
+//export dump the gitlab group as Forjfile fragments and a deploy file, printed or written in the output directory.
+func (a *GitlabApp) export() {
+	gls := GitlabPlugin{
+		token: *a.params.token,
+		app:   &AppInstanceStruct{SshServer: *a.params.sshServer},
+	}
+	ret := newPluginData()
+
+	if gls.gitlabConnect(*a.params.server, ret) == nil {
+		kingpin.Fatalf("%s", ret.ErrorMessage)
+	}
+
+	gls.gitlabDeploy.Group = strings.Trim(*a.params.group, "/")
+	gls.gitlabDeploy.ProdGroup = gls.gitlabDeploy.Group
+	gls.gitlabDeploy.GroupDisplayName = gls.gitlabDeploy.Group
+	gls.gitlabDeploy.Projects = make(map[string]ProjectStruct)
+
+	forjfile, err := gls.exportGroup(ret)
+	if err != nil {
+		kingpin.Fatalf("Unable to export '%s'. %s", gls.gitlabDeploy.Group, err)
+	}
+
+	fragment, err := yaml.Marshal(forjfile)
+	if err != nil {
+		kingpin.Fatalf("Unable to encode the Forjfile fragment in yaml. %s", err)
+	}
+
+	if *a.params.output == "" {
+		gls.gitlabDeploy.Version = deployVersion
+		deploy, err := yaml.Marshal(&gls.gitlabDeploy)
+		if err != nil {
+			kingpin.Fatalf("Unable to encode gitlab data in yaml. %s", err)
+		}
+		fmt.Printf("# %s\n%s---\n# %s\n%s", forjfileFragment, fragment, gitlabFile, deploy)
+		return
+	}
+
+	if err := os.MkdirAll(*a.params.output, 0755); err != nil {
+		kingpin.Fatalf("Unable to create '%s'. %s", *a.params.output, err)
+	}
+	fragmentFile := path.Join(*a.params.output, forjfileFragment)
+	if err := ioutil.WriteFile(fragmentFile, fragment, 0644); err != nil {
+		kingpin.Fatalf("Unable to save '%s'. %s", fragmentFile, err)
+	}
+	if _, err := gls.saveYaml(&gls.gitlabDeploy, path.Join(*a.params.output, gitlabFile)); err != nil {
+		kingpin.Fatalf("%s", err)
+	}
+	log.Printf("'%s' exported in '%s'. Review them before adding them to your Forjfile and deploy repository.", gls.gitlabDeploy.Group, *a.params.output)
+}
+
+//exportGroup read the group projects, subgroups, members and webhooks in deploy data and return the Forjfile entries.
+func (gls *GitlabPlugin) exportGroup(ret *goforjj.PluginData) (forjfile map[string]forjfileEntries, err error) {
+	group, _, err := gls.Client.Groups.GetGroup(gls.gitlabDeploy.Group)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get '%s' group information. %s", gls.gitlabDeploy.Group, err)
+	}
+	gls.gitlabDeploy.GroupId = group.ID
+	if group.Visibility != nil {
+		gls.gitlabDeploy.GroupVisibility = string(*group.Visibility)
+	}
+	gls.gitlabDeploy.GroupHookPolicy = "sync"
+	gls.gitlabDeploy.RemovedProjectsPolicy = "mark"
+
+	if err = gls.importProjects(ret); err != nil {
+		return
+	}
+	if err = gls.exportSubgroups(group); err != nil {
+		return
+	}
+	if err = gls.exportGroupMembers(group); err != nil {
+		return
+	}
+
+	hooks, err := gls.listHooks(fmt.Sprintf("groups/%d/hooks", group.ID))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get '%s' group webhooks. %s", group.FullPath, err)
+	}
+	for hookUrl, hook := range hooks {
+		if gls.gitlabDeploy.GroupHooks == nil {
+			gls.gitlabDeploy.GroupHooks = make(map[string]WebHookStruct)
+		}
+		gls.gitlabDeploy.GroupHooks[fmt.Sprintf("group-hook-%d", hook.ID)] = importedHook(hookUrl, hook)
+	}
+
+	return gls.forjfileEntries(), nil
+}
+
+//exportSubgroups set deploy groups from the group subgroups. The group role is the most used members access level.
+func (gls *GitlabPlugin) exportSubgroups(group *gitlab.Group) error {
+	options := &gitlab.ListSubgroupsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		subgroups, resp, err := gls.Client.Groups.ListSubgroups(group.ID, options)
+		if err != nil {
+			return fmt.Errorf("Unable to get '%s' subgroups. %s", group.FullPath, err)
+		}
+		for _, subgroup := range subgroups {
+			members, err := gls.groupMembers(subgroup.ID)
+			if err != nil {
+				return fmt.Errorf("Unable to get '%s' group members. %s", subgroup.FullPath, err)
+			}
+
+			groupData := GroupStruct{Name: subgroup.Path}
+			levels := make(map[string]int)
+			for name, member := range members {
+				if name == gls.user.Username {
+					continue
+				}
+				groupData.Members = append(groupData.Members, name)
+				levels[accessLevelName(member.AccessLevel)]++
+			}
+			sort.Strings(groupData.Members)
+			for level, count := range levels {
+				if groupData.Role == "" || count > levels[groupData.Role] || (count == levels[groupData.Role] && level < groupData.Role) {
+					groupData.Role = level
+				}
+			}
+			if len(levels) > 1 {
+				log.Printf("Group '%s' members have different access levels. '%s' is used for all of them.", subgroup.FullPath, groupData.Role)
+			}
+
+			if gls.gitlabDeploy.Groups == nil {
+				gls.gitlabDeploy.Groups = make(map[string]GroupStruct)
+			}
+			gls.gitlabDeploy.Groups[subgroup.Path] = groupData
+		}
+		if resp.NextPage == 0 {
+			return nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+//exportGroupMembers set deploy users from the group members. The token user is ignored.
+func (gls *GitlabPlugin) exportGroupMembers(group *gitlab.Group) error {
+	members, err := gls.groupMembers(group.ID)
+	if err != nil {
+		return fmt.Errorf("Unable to get '%s' group members. %s", group.FullPath, err)
+	}
+	for name, member := range members {
+		if name == gls.user.Username {
+			continue
+		}
+		if gls.gitlabDeploy.Users == nil {
+			gls.gitlabDeploy.Users = make(map[string]string)
+		}
+		gls.gitlabDeploy.Users[name] = accessLevelName(member.AccessLevel)
+	}
+	return nil
+}
+
+//memberList return members as a Forjfile list: name:level,... Project members can't be owner.
+func memberList(members map[string]string) string {
+	var list []string
+	for name, level := range members {
+		if level == "owner" {
+			level = "maintainer"
+		}
+		list = append(list, name+":"+level)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+//add set the Forjfile entry attribute if the value is set.
+func (e forjfileEntries) add(name, attribute string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+	case *bool:
+		if v == nil {
+			return
+		}
+		value = strconv.FormatBool(*v)
+	case *int:
+		if v == nil {
+			return
+		}
+		value = strconv.Itoa(*v)
+	case bool:
+		value = strconv.FormatBool(v)
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	}
+	if e[name] == nil {
+		e[name] = make(map[string]interface{})
+	}
+	e[name][attribute] = value
+}
+
+//addShared add the repo to the Forjfile entry identified by its attributes (webhooks and protections shared by repos).
+// The entry name is prefix-<index> for new entries.
+func (e forjfileEntries) addShared(prefix string, attributes map[string]interface{}, repo string) {
+	for _, entry := range e {
+		//same attributes set, repos excluded.
+		same := (len(entry)-1 == len(attributes))
+		for attribute, value := range attributes {
+			if !same {
+				break
+			}
+			found, ok := entry[attribute]
+			same = ok && fmt.Sprint(found) == fmt.Sprint(value)
+		}
+		if same {
+			entry["repos"] = entry["repos"].(string) + "," + repo
+			return
+		}
+	}
+	attributes["repos"] = repo
+	e[fmt.Sprintf("%s-%d", prefix, len(e)+1)] = attributes
+}
+
+//forjfileEntries return Forjfile entries equivalent to the deploy data.
+func (gls *GitlabPlugin) forjfileEntries() map[string]forjfileEntries {
+	repos, groups, users := make(forjfileEntries), make(forjfileEntries), make(forjfileEntries)
+	webhooks, protections := make(forjfileEntries), make(forjfileEntries)
+
+	projects := make([]string, 0, len(gls.gitlabDeploy.Projects))
+	for name := range gls.gitlabDeploy.Projects {
+		projects = append(projects, name)
+	}
+	sort.Strings(projects)
+
+	for _, name := range projects {
+		r := gls.gitlabDeploy.Projects[name]
+		repos.add(name, "title", r.Description)
+		repos.add(name, "issue_tracker", r.IssueTracker)
+		repos.add(name, "visibility", r.Visibility)
+		repos.add(name, "default-branch", r.DefaultBranch)
+		if r.Disabled {
+			repos.add(name, "disabled", true)
+		}
+		repos.add(name, "users", memberList(r.Users))
+		repos.add(name, "groups", memberList(r.Groups))
+		repos.add(name, "merge-method", r.MergeRequests.Method)
+		repos.add(name, "squash", r.MergeRequests.Squash)
+		repos.add(name, "remove-source-branch", r.MergeRequests.RemoveSourceBranch)
+		repos.add(name, "pipelines-must-succeed", r.MergeRequests.PipelinesMustSucceed)
+		repos.add(name, "discussions-must-be-resolved", r.MergeRequests.DiscussionsMustBeResolved)
+		repos.add(name, "approvals", r.MergeRequests.Approvals)
+		repos.add(name, "wiki", r.Features.Wiki)
+		repos.add(name, "snippets", r.Features.Snippets)
+		repos.add(name, "container-registry", r.Features.ContainerRegistry)
+		repos.add(name, "lfs", r.Features.LFS)
+		repos.add(name, "packages", r.Features.Packages)
+		repos.add(name, "pages", r.Features.Pages)
+		repos.add(name, "ci-cd", r.Features.CICD)
+
+		//sorted: shared entries names depend on the order they are added.
+		hooks := make(map[string]WebHookStruct)
+		hookUrls := make([]string, 0, len(r.WebHooks))
+		for _, hook := range r.WebHooks {
+			hooks[hook.Url] = hook
+			hookUrls = append(hookUrls, hook.Url)
+		}
+		sort.Strings(hookUrls)
+		for _, hookUrl := range hookUrls {
+			hook := hooks[hookUrl]
+			webhooks.addShared("hook", map[string]interface{}{
+				"url":       hook.Url,
+				"events":    strings.Join(hook.Events, ","),
+				"ssl-check": strconv.FormatBool(hook.SSLCheck),
+			}, name)
+		}
+		for _, kind := range []string{"branch", "tag"} {
+			list := r.ProtectedBranches
+			if kind == "tag" {
+				list = r.ProtectedTags
+			}
+			patterns := make([]string, 0, len(list))
+			for pattern := range list {
+				patterns = append(patterns, pattern)
+			}
+			sort.Strings(patterns)
+			for _, pattern := range patterns {
+				p := list[pattern]
+				attributes := map[string]interface{}{"kind": kind, "pattern": pattern, "push": p.Push}
+				if kind == "branch" {
+					attributes["merge"] = p.Merge
+					attributes["unprotect"] = p.Unprotect
+					attributes["code-owner-approval"] = strconv.FormatBool(p.CodeOwnerApproval)
+				}
+				if len(p.Users) > 0 {
+					attributes["users"] = strings.Join(p.Users, ",")
+				}
+				if len(p.Groups) > 0 {
+					attributes["groups"] = strings.Join(p.Groups, ",")
+				}
+				protections.addShared("protection", attributes, name)
+			}
+		}
+	}
+
+	for name, hook := range gls.gitlabDeploy.GroupHooks {
+		webhooks.add(name, "url", hook.Url)
+		webhooks.add(name, "events", strings.Join(hook.Events, ","))
+		webhooks.add(name, "ssl-check", hook.SSLCheck)
+		webhooks.add(name, "group", true)
+	}
+	for name, group := range gls.gitlabDeploy.Groups {
+		groups.add(name, "role", group.Role)
+		groups.add(name, "members", group.Members)
+	}
+	for name, role := range gls.gitlabDeploy.Users {
+		users.add(name, "role", role)
+	}
+
+	//names are set by the Forjfile entry key
+	forjfile := make(map[string]forjfileEntries)
+	for object, entries := range map[string]forjfileEntries{
+		"repositories": repos, "groups": groups, "users": users, "webhooks": webhooks, "protections": protections,
+	} {
+		if len(entries) > 0 {
+			forjfile[object] = entries
+		}
+	}
+	return forjfile
+}
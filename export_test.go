@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForjfileEntries(t *testing.T) {
+	hooks := map[string]WebHookStruct{
+		"ci":    {Url: "https://ci.example.com/hook", Events: []string{"push"}, SSLCheck: true},
+		"chat":  {Url: "https://chat.example.com/hook", Events: []string{"merge_requests"}},
+		"audit": {Url: "https://audit.example.com/hook"},
+	}
+	protected := map[string]ProtectionStruct{
+		"main":      {Push: "no-one", Merge: "developer", Unprotect: "maintainer"},
+		"release/*": {Push: "maintainer", Merge: "maintainer", Unprotect: "maintainer"},
+	}
+	gls := GitlabPlugin{}
+	gls.gitlabDeploy.Projects = map[string]ProjectStruct{
+		"api": {Name: "api", WebHooks: hooks, ProtectedBranches: protected, ProtectedTags: map[string]ProtectionStruct{"v*": {Push: "maintainer"}}},
+		"web": {Name: "web", WebHooks: map[string]WebHookStruct{"ci": hooks["ci"]}, ProtectedBranches: protected},
+		"doc": {Name: "doc", WebHooks: map[string]WebHookStruct{"ci": {Url: "https://ci.example.com/hook", Events: []string{"push", "tag_push"}}}},
+	}
+
+	entries := gls.forjfileEntries()
+	for i := 0; i < 20; i++ {
+		if again := gls.forjfileEntries(); !reflect.DeepEqual(again, entries) {
+			t.Fatalf("forjfileEntries is not stable: %v, then %v", entries, again)
+		}
+	}
+
+	tests := []struct {
+		object string
+		name   string
+		key    string
+		value  string
+	}{
+		{"webhooks", "hook-1", "url", "https://audit.example.com/hook"},
+		{"webhooks", "hook-1", "repos", "api"},
+		{"webhooks", "hook-2", "url", "https://chat.example.com/hook"},
+		{"webhooks", "hook-3", "url", "https://ci.example.com/hook"},
+		{"webhooks", "hook-3", "repos", "api,web"},
+		{"webhooks", "hook-4", "events", "push,tag_push"},
+		{"webhooks", "hook-4", "repos", "doc"},
+		{"protections", "protection-1", "pattern", "main"},
+		{"protections", "protection-1", "repos", "api,web"},
+		{"protections", "protection-2", "pattern", "release/*"},
+		{"protections", "protection-2", "repos", "api,web"},
+		{"protections", "protection-3", "kind", "tag"},
+		{"protections", "protection-3", "repos", "api"},
+	}
+	for _, test := range tests {
+		if value := entries[test.object][test.name][test.key]; value != test.value {
+			t.Errorf("forjfileEntries: %s '%s' %s is '%v', expected '%s'", test.object, test.name, test.key, value, test.value)
+		}
+	}
+	if count := len(entries["webhooks"]); count != 4 {
+		t.Errorf("forjfileEntries: %d webhooks, expected 4", count)
+	}
+	if count := len(entries["protections"]); count != 3 {
+		t.Errorf("forjfileEntries: %d protections, expected 3", count)
+	}
+}
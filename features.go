@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//FeaturesStruct project features toggles. Unset features are not managed.
+type FeaturesStruct struct {
+	Wiki              *bool `yaml:",omitempty"`
+	Snippets          *bool `yaml:",omitempty"`
+	ContainerRegistry *bool `yaml:"container-registry,omitempty"`
+	LFS               *bool `yaml:"lfs,omitempty"`
+	Packages          *bool `yaml:",omitempty"`
+	Pages             *bool `yaml:",omitempty"`
+	CICD              *bool `yaml:"ci-cd,omitempty"`
+}
+
+//gitlabFeatureSettings project features not implemented by go-gitlab
+type gitlabFeatureSettings struct {
+	PackagesEnabled  *bool  `json:"packages_enabled,omitempty"`
+	PagesAccessLevel string `json:"pages_access_level,omitempty"` // disabled, private, enabled or public
+}
+
+//isValidFeatures verify the repo features toggles.
+func (r *RepoInstanceStruct) isValidFeatures() error {
+	features := map[string]string{
+		"wiki":               r.Wiki,
+		"snippets":           r.Snippets,
+		"container-registry": r.ContainerRegistry,
+		"lfs":                r.Lfs,
+		"packages":           r.Packages,
+		"pages":              r.Pages,
+		"ci-cd":              r.CiCd,
+	}
+	for name, value := range features {
+		if value != "" && value != "true" && value != "false" {
+			return fmt.Errorf("%s must be 'true' or 'false'. Got '%s'.", name, value)
+		}
+	}
+	return nil
+}
+
+//set features from the forjj repo validated by isValid.
+func (f *FeaturesStruct) set(project *RepoInstanceStruct) *FeaturesStruct {
+	if f == nil {
+		f = new(FeaturesStruct)
+	}
+	f.Wiki = boolFlag(project.Wiki)
+	f.Snippets = boolFlag(project.Snippets)
+	f.ContainerRegistry = boolFlag(project.ContainerRegistry)
+	f.LFS = boolFlag(project.Lfs)
+	f.Packages = boolFlag(project.Packages)
+	f.Pages = boolFlag(project.Pages)
+	f.CICD = boolFlag(project.CiCd)
+	return f
+}
+
+//createOptions set features supported by the project creation.
+func (f *FeaturesStruct) createOptions(options *gitlab.CreateProjectOptions) {
+	options.WikiEnabled = f.Wiki
+	options.SnippetsEnabled = f.Snippets
+	options.ContainerRegistryEnabled = f.ContainerRegistry
+	options.LFSEnabled = f.LFS
+	options.JobsEnabled = f.CICD
+}
+
+//changes compare the gitlab project with features and set changed attributes in options.
+func (f *FeaturesStruct) changes(project *gitlab.Project, options *gitlab.EditProjectOptions) (changes []attributeChange) {
+	toggle := func(name string, live bool, declared *bool, option **bool) {
+		if declared == nil || live == *declared {
+			return
+		}
+		*option = declared
+		changes = append(changes, changed(name, live, *declared))
+	}
+
+	toggle("wiki", project.WikiEnabled, f.Wiki, &options.WikiEnabled)
+	toggle("snippets", project.SnippetsEnabled, f.Snippets, &options.SnippetsEnabled)
+	toggle("container registry", project.ContainerRegistryEnabled, f.ContainerRegistry, &options.ContainerRegistryEnabled)
+	toggle("lfs", project.LFSEnabled, f.LFS, &options.LFSEnabled)
+	toggle("ci/cd", project.JobsEnabled, f.CICD, &options.JobsEnabled)
+	return
+}
+
+//ensureFeatures maintain project features not implemented by go-gitlab (packages and pages).
+// project is nil if not created yet (plan mode).
+func (r *ProjectStruct) ensureFeatures(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	f := &r.Features
+	if project == nil || (f.Packages == nil && f.Pages == nil) {
+		return nil
+	}
+
+	projectPath := fmt.Sprintf("projects/%d", project.ID)
+	live := new(gitlabFeatureSettings)
+	if err := gls.apiRequest("GET", projectPath, nil, live); err != nil {
+		ret.Errorf("Unable to get '%s' features. %s", r.Name, err)
+		return err
+	}
+
+	options := new(gitlabFeatureSettings)
+	var changes []attributeChange
+	if f.Packages != nil {
+		current := live.PackagesEnabled != nil && *live.PackagesEnabled
+		if current != *f.Packages {
+			options.PackagesEnabled = f.Packages
+			changes = append(changes, changed("packages", current, *f.Packages))
+		}
+	}
+	if f.Pages != nil {
+		//private and public pages are enabled pages.
+		current := (live.PagesAccessLevel != "" && live.PagesAccessLevel != "disabled")
+		if current != *f.Pages {
+			options.PagesAccessLevel = "disabled"
+			if *f.Pages {
+				options.PagesAccessLevel = "enabled"
+			}
+			changes = append(changes, changed("pages", current, *f.Pages))
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if gls.plannedChanges("project", r.Name, "update", changes) {
+		return nil
+	}
+	if err := gls.apiRequest("PUT", projectPath, options, nil); err != nil {
+		ret.Errorf("Unable to update '%s' features. %s", r.Name, err)
+		return err
+	}
+	for _, change := range changes {
+		log.Printf(ret.StatusAdd("Repo '%s': %s", r.Name, change))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+//flowStruct gitlab settings applied to projects using the flow
+type flowStruct struct {
+	DefaultBranch     string
+	ProtectedBranches map[string]ProtectionStruct
+	MergeRequests     MergeRequestsStruct
+}
+
+//flows gitlab workflow presets by forjj flow name. Approvals are not preset, they are set only when declared.
+var flows = map[string]flowStruct{
+	//trunk based: short lived branches merged in main with merge requests.
+	"trunk": {
+		DefaultBranch: "main",
+		ProtectedBranches: map[string]ProtectionStruct{
+			"main": {Push: "no-one", Merge: "developer", Unprotect: "maintainer"},
+		},
+		MergeRequests: MergeRequestsStruct{
+			Method:               "ff",
+			Squash:               "default_on",
+			PipelinesMustSucceed: gitlab.Bool(true),
+		},
+	},
+	//git-flow: features merged in develop, releases merged in master.
+	"git-flow": {
+		DefaultBranch: "develop",
+		ProtectedBranches: map[string]ProtectionStruct{
+			"master":    {Push: "no-one", Merge: "maintainer", Unprotect: "maintainer"},
+			"develop":   {Push: "no-one", Merge: "developer", Unprotect: "maintainer"},
+			"release/*": {Push: "maintainer", Merge: "developer", Unprotect: "maintainer"},
+			"hotfix/*":  {Push: "maintainer", Merge: "developer", Unprotect: "maintainer"},
+		},
+		MergeRequests: MergeRequestsStruct{
+			Method:               "merge",
+			Squash:               "default_off",
+			PipelinesMustSucceed: gitlab.Bool(true),
+		},
+	},
+}
+
+//defaultBranch return the project default branch, declared or set by the flow.
+func (r *ProjectStruct) defaultBranch() string {
+	if r.DefaultBranch != "" {
+		return r.DefaultBranch
+	}
+	return flows[r.Flow].DefaultBranch
+}
+
+//applyFlow complete project settings with the flow preset. Settings declared on the project take precedence.
+// Flows unknown by forjj-gitlab are ignored.
+func (r *ProjectStruct) applyFlow() {
+	if r.Flow == "" {
+		return
+	}
+	flow, found := flows[r.Flow]
+	if !found {
+		log.Printf("Repo '%s': flow '%s' has no gitlab preset. Ignored.", r.Name, r.Flow)
+		return
+	}
+
+	if r.DefaultBranch == "" {
+		r.DefaultBranch = flow.DefaultBranch
+	}
+	//copied: the map is shared with the deploy data.
+	protectedBranches := make(map[string]ProtectionStruct)
+	for pattern, protection := range flow.ProtectedBranches {
+		protectedBranches[pattern] = protection
+	}
+	for pattern, protection := range r.ProtectedBranches {
+		protectedBranches[pattern] = protection
+	}
+	r.ProtectedBranches = protectedBranches
+	r.MergeRequests.complete(&flow.MergeRequests)
+}
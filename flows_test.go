@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestApplyFlow(t *testing.T) {
+	declared := ProtectionStruct{Push: "maintainer", Merge: "maintainer", Unprotect: "maintainer"}
+	tests := []struct {
+		name     string
+		project  ProjectStruct
+		expected ProjectStruct
+	}{
+		{"no flow", ProjectStruct{Name: "app"}, ProjectStruct{Name: "app"}},
+		{"unknown flow",
+			ProjectStruct{Name: "app", Flow: "unknown", ProtectedBranches: map[string]ProtectionStruct{"main": declared}},
+			ProjectStruct{Name: "app", Flow: "unknown", ProtectedBranches: map[string]ProtectionStruct{"main": declared}}},
+		{"trunk",
+			ProjectStruct{Name: "app", Flow: "trunk"},
+			ProjectStruct{Name: "app", Flow: "trunk", DefaultBranch: "main",
+				ProtectedBranches: map[string]ProtectionStruct{"main": flows["trunk"].ProtectedBranches["main"]},
+				MergeRequests:     MergeRequestsStruct{Method: "ff", Squash: "default_on", PipelinesMustSucceed: gitlab.Bool(true)}}},
+		{"declared values win",
+			ProjectStruct{Name: "app", Flow: "trunk", DefaultBranch: "develop",
+				ProtectedBranches: map[string]ProtectionStruct{"main": declared, "develop": declared},
+				MergeRequests:     MergeRequestsStruct{Method: "merge", PipelinesMustSucceed: gitlab.Bool(false)}},
+			ProjectStruct{Name: "app", Flow: "trunk", DefaultBranch: "develop",
+				ProtectedBranches: map[string]ProtectionStruct{"main": declared, "develop": declared},
+				MergeRequests:     MergeRequestsStruct{Method: "merge", Squash: "default_on", PipelinesMustSucceed: gitlab.Bool(false)}}},
+		{"git-flow",
+			ProjectStruct{Name: "app", Flow: "git-flow", ProtectedBranches: map[string]ProtectionStruct{"master": declared}},
+			ProjectStruct{Name: "app", Flow: "git-flow", DefaultBranch: "develop",
+				ProtectedBranches: map[string]ProtectionStruct{
+					"master":    declared,
+					"develop":   flows["git-flow"].ProtectedBranches["develop"],
+					"release/*": flows["git-flow"].ProtectedBranches["release/*"],
+					"hotfix/*":  flows["git-flow"].ProtectedBranches["hotfix/*"],
+				},
+				MergeRequests: MergeRequestsStruct{Method: "merge", Squash: "default_off", PipelinesMustSucceed: gitlab.Bool(true)}}},
+	}
+
+	presets := make(map[string]map[string]ProtectionStruct)
+	for name, flow := range flows {
+		presets[name] = make(map[string]ProtectionStruct)
+		for pattern, protection := range flow.ProtectedBranches {
+			presets[name][pattern] = protection
+		}
+	}
+
+	for _, test := range tests {
+		project := test.project
+		var protectedBranches map[string]ProtectionStruct
+		if test.project.ProtectedBranches != nil {
+			protectedBranches = make(map[string]ProtectionStruct)
+			for pattern, protection := range test.project.ProtectedBranches {
+				protectedBranches[pattern] = protection
+			}
+		}
+
+		project.applyFlow()
+		if !reflect.DeepEqual(project, test.expected) {
+			t.Errorf("applyFlow(%s): %+v, expected %+v", test.name, project, test.expected)
+		}
+		if project.MergeRequests.Approvals != nil {
+			t.Errorf("applyFlow(%s): approvals preset to %d", test.name, *project.MergeRequests.Approvals)
+		}
+		if !reflect.DeepEqual(test.project.ProtectedBranches, protectedBranches) {
+			t.Errorf("applyFlow(%s): declared protected branches changed to %v", test.name, test.project.ProtectedBranches)
+		}
+		for name, flow := range flows {
+			if !reflect.DeepEqual(flow.ProtectedBranches, presets[name]) {
+				t.Errorf("applyFlow(%s): flow '%s' protected branches changed to %v", test.name, name, flow.ProtectedBranches)
+			}
+		}
+	}
+}
@@ -13,15 +13,20 @@ package main
 type AppInstanceStruct struct {
 	ForjjGroup string `json:"forjj-group"` // Default FORJJ group. Used by default as gitlab group. If you want different one, use --gitlab-group
 	ForjjInfra string `json:"forjj-infra"` // Name of the Infra repository to use in github if requested.
-	Group string `json:"group"` // Gitlab group name.
-	OrgHookPolicy string `json:"org-hook-policy"` // Set 'sync' to manage all repository webhooks. set 'manage' to manage only listed.
-	OrganizationWebhooksDisabled string `json:"organization-webhooks-disabled"` // true if the plugin should not manage github organization webhooks.
+	Group string `json:"group"` // Gitlab group full path (ex: acme/platform/infra). Missing subgroups are created. By default, it uses the Forjj group name
+	GroupVisibility string `json:"group-visibility"` // Visibility of the groups created by forjj: private (default), internal or public.
+	Import string `json:"import"` // true to adopt the projects existing in the gitlab group at create. Projects not declared in the Forjfile are imported with their gitlab settings and kept until declared. Changes to declared projects are reported.
+	OrgHookPolicy string `json:"org-hook-policy"` // Set 'sync' to manage all group webhooks. set 'manage' to manage only listed.
+	OrganizationWebhooksDisabled string `json:"organization-webhooks-disabled"` // true if the plugin should not manage gitlab group webhooks.
 	ProDeployment string `json:"pro-deployment"` // true if current deployment is production one
 	ProductionGroup string `json:"production-group"` // Production github organization name. By default, it uses the FORJJ organization name
 	ProjectsDisabled string `json:"projects-disabled"` // true if the plugin should not manage github repositories except the infra repository.
-	ProjectsWebhooksDisabled string `json:"projects-webhooks-disabled"` // true if the plugin should not manage github repositories webhooks.
-	Server string `json:"server"` // Github Enterprise Server name. By default, public 'github.com' API is used.
+	ProjectsWebhooksDisabled string `json:"projects-webhooks-disabled"` // true if the plugin should not manage gitlab projects webhooks.
+	RemovedProjectsPolicy string `json:"removed-projects-policy"` // What to do with projects removed from the Forjfile. 'mark' keeps them in the deploy file as removed and leaves them in gitlab. 'archive' archives them in gitlab. 'delete' deletes them from gitlab with maintain --force only. 'remove' forgets them.
+	Server string `json:"server"` // Gitlab server url or name ([https://]host[:port][/path]). By default, public 'gitlab.com' is used.
+	SshServer string `json:"ssh-server"` // Gitlab SSH server (host[:port]). By default, the server host is used on port 22.
 	Token string `json:"token"` // Github token to access. This token must authorize organization level access.
+	Visibility string `json:"visibility"` // Default visibility of the repositories: private, internal or public. By default, gitlab visibility is kept.
 
 }
 
@@ -35,7 +40,28 @@ type AppInstanceStruct struct {
 type GroupInstanceStruct struct {
 	Members []string `json:"members"` // List of users to attach to the new group.
 	Name string `json:"name"` // group name
-	Role string `json:"role"` // List of roles to apply to the new group.
+	Role string `json:"role"` // Access level of the group members: guest, reporter, developer (default), maintainer or owner.
+
+}
+
+// Object protections groups structure
+
+// Groups structure
+
+
+// Object Instance structures
+
+type ProtectionsInstanceStruct struct {
+	CodeOwnerApproval string `json:"code-owner-approval"` // true to require code owner approval to push or merge (branch only).
+	Groups string `json:"groups"` // List of groups (full path) allowed to push and merge (branch) or create (tag), separated by comma.
+	Kind string `json:"kind"` // 'branch' to protect branches, 'tag' to protect tags.
+	Merge string `json:"merge"` // Access level allowed to merge (branch only): no-one, developer, maintainer or admin.
+	Name string `json:"name"` // Protection name
+	Pattern string `json:"pattern"` // Branch or tag name to protect. Wildcards are accepted (ex: release-*).
+	Push string `json:"push"` // Access level allowed to push (branch) or create (tag): no-one, developer, maintainer or admin.
+	Repos string `json:"repos"` // List of repositories to protect, separated by comma.
+	Unprotect string `json:"unprotect"` // Access level allowed to unprotect (branch only): developer, maintainer or admin.
+	Users string `json:"users"` // List of users allowed to push and merge (branch) or create (tag), separated by comma.
 
 }
 
@@ -47,16 +73,35 @@ type GroupInstanceStruct struct {
 // Object Instance structures
 
 type RepoInstanceStruct struct {
+	ApprovalRules string `json:"approval-rules"` // List of approval rules separated by comma. Format: name:approvals[:member+member...]. Group members are prefixed by '@' (ex: security:1:@acme/security+john).
+	Approvals string `json:"approvals"` // Number of approvals required before merge.
+	CiCd string `json:"ci-cd"` // true to enable CI/CD pipelines, false to disable them.
+	ContainerRegistry string `json:"container-registry"` // true to enable the container registry, false to disable it.
+	DefaultBranch string `json:"default-branch"` // Default branch of the repository, connected to the origin remote. If not set, the gitlab default branch is kept ('master' for new repositories).
 	Deployable string `json:"Deployable"` // true if the repo is identified by forjj as deployable in the current deployment context
-	Flow string `json:"flow"` // Flow activated on this repository
+	Disabled string `json:"disabled"` // true to archive the repository in gitlab. Archived repositories are unarchived when disabled is not true.
+	DiscussionsMustBeResolved string `json:"discussions-must-be-resolved"` // true to allow merge only if all discussions are resolved.
+	Flow string `json:"flow"` // Flow applied to this repository: 'trunk' (merge requests to main, fast-forward merge) or 'git-flow' (develop, master, release/* and hotfix/* branches). Repository settings take precedence over the flow settings.
 	ForjjWorkspaceMount string `json:"forjj-workspace-mount"` // Where the workspace dir is located in the github plugin container.
-	Groups string `json:"groups"` // List of groups to attach to the repository, separated by comma.
+	FormerName string `json:"former-name"` // Previous name of the repository. Set it when renaming the repository to rename the gitlab project instead of creating a new one.
+	Groups string `json:"groups"` // List of groups (full path) to share the repository with, separated by comma. Format: [+-]group[:guest|reporter|developer|maintainer]. Default access level is developer. '-group' and groups removed from the list are unshared.
 	Issue_tracker string `json:"issue_tracker"` // To activate the Issue tracker to the Repository
+	Lfs string `json:"lfs"` // true to enable Git LFS, false to disable it.
+	MergeMethod string `json:"merge-method"` // Merge requests merge method: merge, rebase_merge or ff (fast-forward).
 	Name string `json:"name"` // Repository name
+	Packages string `json:"packages"` // true to enable the packages registry, false to disable it.
+	Pages string `json:"pages"` // true to enable pages, false to disable them.
+	PipelinesMustSucceed string `json:"pipelines-must-succeed"` // true to allow merge only if the pipeline succeeds.
+	ProtectionsManagement string `json:"protections-management"` // Set 'sync' to manage all repository protected branches and tags, except the default branch protection if not listed. set 'manage' to manage only listed.
+	RemoveSourceBranch string `json:"remove-source-branch"` // true to delete the source branch by default when merging.
 	Role string `json:"role"` // Role of the repository. Forjj will set it to 'infra', 'deploy' or 'code'
+	Snippets string `json:"snippets"` // true to enable snippets, false to disable them.
+	Squash string `json:"squash"` // Squash commits when merging: never, always, default_on or default_off.
 	Title string `json:"title"` // Github Repository title
-	Users string `json:"users"` // List of users to attach to the repository, separated by comma.
+	Users string `json:"users"` // List of users to attach to the repository, separated by comma. Format: [+-]user[:guest|reporter|developer|maintainer]. Default access level is developer. '-user' and users removed from the list are revoked with force.
+	Visibility string `json:"visibility"` // Repository visibility: private, internal or public. By default, the app visibility is used.
 	WebhooksManagement string `json:"webhooks-management"` // Set 'sync' to manage all repository webhooks. set 'manage' to manage only listed.
+	Wiki string `json:"wiki"` // true to enable the wiki, false to disable it.
 
 }
 
@@ -74,6 +119,25 @@ type UserInstanceStruct struct {
 }
 
 
+// Object webhooks groups structure
+
+// Groups structure
+
+
+// Object Instance structures
+
+type WebhooksInstanceStruct struct {
+	Events string `json:"events"` // List of events separated by comma: push, tag_push, issues, confidential_issues, merge_requests, note, job, pipeline or wiki_page.
+	Group string `json:"group"` // true to attach the webhook to the gitlab group.
+	Name string `json:"name"` // Webhook name
+	Repos string `json:"repos"` // List of repositories to attach the webhook to, separated by comma.
+	SecretToken string `json:"secret-token"` // Secret token sent with the webhook payload.
+	SslCheck string `json:"ssl-check"` // true to verify the SSL certificate of the payload url.
+	Url string `json:"url"` // Webhook payload url
+
+}
+
+
 // ************************
 // Create request structure
 // ************************
@@ -102,8 +166,10 @@ type CreateReq struct {
 type CreateArgReq struct {
 	App map[string]AppInstanceStruct `json:"app"` // Object details
 	Group map[string]GroupInstanceStruct `json:"group"` // Object details
+	Protections map[string]ProtectionsInstanceStruct `json:"protections"` // Object details
 	Repo map[string]RepoInstanceStruct `json:"repo"` // Object details
 	User map[string]UserInstanceStruct `json:"user"` // Object details
+	Webhooks map[string]WebhooksInstanceStruct `json:"webhooks"` // Object details
 }
 
 // ************************
@@ -123,8 +189,10 @@ type UpdateReq struct {
 type UpdateArgReq struct {
 	App map[string]AppInstanceStruct `json:"app"` // Object details
 	Group map[string]GroupInstanceStruct `json:"group"` // Object details
+	Protections map[string]ProtectionsInstanceStruct `json:"protections"` // Object details
 	Repo map[string]RepoInstanceStruct `json:"repo"` // Object details
 	User map[string]UserInstanceStruct `json:"user"` // Object details
+	Webhooks map[string]WebhooksInstanceStruct `json:"webhooks"` // Object details
 }
 
 // **************************
@@ -137,6 +205,7 @@ type MaintainReq struct {
 		DeployTo string `json:"deploy-to"`
 		Force string `json:"force"`
 		ForjjWorkspaceMount string `json:"forjj-workspace-mount"`
+		Plan string `json:"plan"`
 	}
 
 	Objects MaintainArgReq
@@ -146,12 +215,17 @@ type MaintainReq struct {
 
 type MaintainArgReq struct {
 	App map[string]AppMaintainStruct `json:"app"` // Object details
+	Webhooks map[string]WebhooksMaintainStruct `json:"webhooks"` // Object details
 }
 
 type AppMaintainStruct struct {
 	Token string `json:"token"` // Github token to access. This token must authorize organization level access.
 }
 
+type WebhooksMaintainStruct struct {
+	SecretToken string `json:"secret-token"` // Secret token sent with the webhook payload.
+}
+
 
 // YamlDesc has been created from your 'gitlab.yaml' file.
 const YamlDesc = "---\n" +
@@ -182,14 +256,19 @@ const YamlDesc = "---\n" +
    "    forjj-workspace-mount:\n" +
    "      help: \"Where the workspace dir is located in the gitlab plugin container\"\n" +
    "    force:\n" +
-   "      help: Set 'true' to force removal of teams/users when forjj creates a new forge.\n" +
+   "      help: Set 'true' to revoke project members removed from the Forjfile, and to force removal of teams/users when forjj creates a new forge.\n" +
+   "    plan:\n" +
+   "      help: Set 'true' to report changes maintain would apply in gitlab without applying them.\n" +
+   "      default: false\n" +
    "objects: # All objects will be delivered by forjj except workspace/infra under objects/<type>/<instance>/<action>/key=value\n" +
    "  # Define infra object special flag for github\n" +
    "  app: # already defined by Forjj\n" +
    "    # Default is : actions: [\"add\", \"change\", \"remove\"] No need to define it.\n" +
    "    flags:\n" +
    "      server:\n" +
-   "        help: \"Github Enterprise Server name. By default, public 'github.com' API is used.\"\n" +
+   "        help: \"Gitlab server url or name ([https://]host[:port][/path]). By default, public 'gitlab.com' is used.\"\n" +
+   "      ssh-server:\n" +
+   "        help: \"Gitlab SSH server (host[:port]). By default, the server host is used on port 22.\"\n" +
    "      forjj-group:\n" +
    "        only-for-actions: [\"add\"]\n" +
    "        help: \"Default FORJJ group. Used by default as gitlab group. If you want different one, use --gitlab-group\"\n" +
@@ -198,7 +277,7 @@ const YamlDesc = "---\n" +
    "  #      help: \"Github Organization name. By default, it uses the FORJJ organization name\"\n" +
    "      group:\n" +
    "        only-for-actions: [\"add\"]\n" +
-   "        help: \"Gitlab group name.\"\n" +
+   "        help: \"Gitlab group full path (ex: acme/platform/infra). Missing subgroups are created. By default, it uses the Forjj group name\"\n" +
    "      production-group:\n" +
    "        help: \"Production github organization name. By default, it uses the FORJJ organization name\"\n" +
    "        default: \"{{ .Deployments.GetFromPRO \\\"app\\\" .Current.Name \\\"organization\\\" }}\"\n" +
@@ -218,14 +297,24 @@ const YamlDesc = "---\n" +
    "      projects-disabled:\n" +
    "        help: \"true if the plugin should not manage github repositories except the infra repository.\"\n" +
    "        default: false\n" +
+   "      import:\n" +
+   "        help: \"true to adopt the projects existing in the gitlab group at create. Projects not declared in the Forjfile are imported with their gitlab settings and kept until declared. Changes to declared projects are reported.\"\n" +
+   "        default: false\n" +
+   "      removed-projects-policy:\n" +
+   "        help: \"What to do with projects removed from the Forjfile. 'mark' keeps them in the deploy file as removed and leaves them in gitlab. 'archive' archives them in gitlab. 'delete' deletes them from gitlab with maintain --force only. 'remove' forgets them.\"\n" +
+   "        default: mark\n" +
+   "      visibility:\n" +
+   "        help: \"Default visibility of the repositories: private, internal or public. By default, gitlab visibility is kept.\"\n" +
+   "      group-visibility:\n" +
+   "        help: \"Visibility of the groups created by forjj: private (default), internal or public.\"\n" +
    "      organization-webhooks-disabled:\n" +
-   "        help: true if the plugin should not manage github organization webhooks.\n" +
+   "        help: true if the plugin should not manage gitlab group webhooks.\n" +
    "        default: false\n" +
    "      projects-webhooks-disabled:\n" +
-   "        help: true if the plugin should not manage github repositories webhooks.\n" +
+   "        help: true if the plugin should not manage gitlab projects webhooks.\n" +
    "        default: false\n" +
    "      org-hook-policy:\n" +
-   "        help: Set 'sync' to manage all repository webhooks. set 'manage' to manage only listed.\n" +
+   "        help: Set 'sync' to manage all group webhooks. set 'manage' to manage only listed.\n" +
    "        default: sync\n" +
    "      pro-deployment:\n" +
    "        help: true if current deployment is production one\n" +
@@ -233,7 +322,7 @@ const YamlDesc = "---\n" +
    "  # Define github group exposure to forjj\n" +
    "  group: # New object type in forjj\n" +
    "    # Default is : actions: [\"add\", \"change\", \"remove\", \"list\", \"rename\"]\n" +
-   "    help: \"Manage teams in github\"\n" +
+   "    help: \"Manage subgroups of the gitlab group\"\n" +
    "    identified_by_flag: name\n" +
    "    flags:\n" +
    "      members:\n" +
@@ -245,11 +334,11 @@ const YamlDesc = "---\n" +
    "        required: true\n" +
    "      role:\n" +
    "        only-for-actions: [\"add\"]\n" +
-   "        help: \"List of roles to apply to the new group.\"\n" +
+   "        help: \"Access level of the group members: guest, reporter, developer (default), maintainer or owner.\"\n" +
    "  # Define github users exposure to forjj\n" +
    "  user: # New object type in forjj\n" +
    "    # Default is : actions: [\"add\", \"change\", \"remove\", \"list\", \"rename\"]\n" +
-   "    help: \"Manage organization list of users\"\n" +
+   "    help: \"Manage gitlab group members\"\n" +
    "    identified_by_flag: name\n" +
    "    flags:\n" +
    "      name:\n" +
@@ -259,7 +348,67 @@ const YamlDesc = "---\n" +
    "      role:\n" +
    "        only-for-actions: [\"add\"]\n" +
    "        options:\n" +
-   "          help: \"List of roles to apply to the new user.\"\n" +
+   "          help: \"Access level of the user in the gitlab group: guest, reporter, developer (default), maintainer or owner.\"\n" +
+   "  # Define gitlab webhooks exposure to forjj\n" +
+   "  webhooks: # New object type in forjj\n" +
+   "    # Default is : actions: [\"add\", \"change\", \"remove\", \"list\", \"rename\"]\n" +
+   "    help: \"Manage gitlab webhooks\"\n" +
+   "    identified_by_flag: name\n" +
+   "    flags:\n" +
+   "      name:\n" +
+   "        help: \"Webhook name\"\n" +
+   "        required: true\n" +
+   "      url:\n" +
+   "        help: \"Webhook payload url\"\n" +
+   "        required: true\n" +
+   "      events:\n" +
+   "        help: \"List of events separated by comma: push, tag_push, issues, confidential_issues, merge_requests, note, job, pipeline or wiki_page.\"\n" +
+   "        default: push\n" +
+   "      ssl-check:\n" +
+   "        help: \"true to verify the SSL certificate of the payload url.\"\n" +
+   "        default: true\n" +
+   "      group:\n" +
+   "        help: \"true to attach the webhook to the gitlab group.\"\n" +
+   "        default: false\n" +
+   "      repos:\n" +
+   "        help: \"List of repositories to attach the webhook to, separated by comma.\"\n" +
+   "      secret-token:\n" +
+   "        cli-exported-to-actions: [\"maintain\"]\n" +
+   "        help: \"Secret token sent with the webhook payload.\"\n" +
+   "        secure: true\n" +
+   "  # Define gitlab protected branches and tags exposure to forjj\n" +
+   "  protections: # New object type in forjj\n" +
+   "    # Default is : actions: [\"add\", \"change\", \"remove\", \"list\", \"rename\"]\n" +
+   "    help: \"Manage gitlab protected branches and tags\"\n" +
+   "    identified_by_flag: name\n" +
+   "    flags:\n" +
+   "      name:\n" +
+   "        help: \"Protection name\"\n" +
+   "        required: true\n" +
+   "      kind:\n" +
+   "        help: \"'branch' to protect branches, 'tag' to protect tags.\"\n" +
+   "        default: branch\n" +
+   "      pattern:\n" +
+   "        help: \"Branch or tag name to protect. Wildcards are accepted (ex: release-*).\"\n" +
+   "        required: true\n" +
+   "      push:\n" +
+   "        help: \"Access level allowed to push (branch) or create (tag): no-one, developer, maintainer or admin.\"\n" +
+   "        default: maintainer\n" +
+   "      merge:\n" +
+   "        help: \"Access level allowed to merge (branch only): no-one, developer, maintainer or admin.\"\n" +
+   "        default: maintainer\n" +
+   "      unprotect:\n" +
+   "        help: \"Access level allowed to unprotect (branch only): developer, maintainer or admin.\"\n" +
+   "        default: maintainer\n" +
+   "      users:\n" +
+   "        help: \"List of users allowed to push and merge (branch) or create (tag), separated by comma.\"\n" +
+   "      groups:\n" +
+   "        help: \"List of groups (full path) allowed to push and merge (branch) or create (tag), separated by comma.\"\n" +
+   "      code-owner-approval:\n" +
+   "        help: \"true to require code owner approval to push or merge (branch only).\"\n" +
+   "        default: false\n" +
+   "      repos:\n" +
+   "        help: \"List of repositories to protect, separated by comma.\"\n" +
    "  repo: # Enhance Forjj repo object\n" +
    "    actions: [\"add\", \"change\"]\n" +
    "    flags:\n" +
@@ -272,16 +421,56 @@ const YamlDesc = "---\n" +
    "        default: \"true\"\n" +
    "      users:\n" +
    "        only-for-actions: [\"add\"]\n" +
-   "        help: \"List of users to attach to the repository, separated by comma.\"\n" +
-   "        format-regexp: \"[+-]?[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9]))*,\"\n" +
+   "        help: \"List of users to attach to the repository, separated by comma. Format: [+-]user[:guest|reporter|developer|maintainer]. Default access level is developer. '-user' and users removed from the list are revoked with force.\"\n" +
+   "        format-regexp: \"[+-]?[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9]))*(:[a-z]+)?,\"\n" +
    "      groups:\n" +
    "        only-for-actions: [\"add\"]\n" +
-   "        help: \"List of groups to attach to the repository, separated by comma.\"\n" +
-   "        format-regexp: \"[+-]?[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9]))*,\"\n" +
+   "        help: \"List of groups (full path) to share the repository with, separated by comma. Format: [+-]group[:guest|reporter|developer|maintainer]. Default access level is developer. '-group' and groups removed from the list are unshared.\"\n" +
+   "        format-regexp: \"[+-]?[a-zA-Z0-9]([a-zA-Z0-9/-]*[a-zA-Z0-9])?([a-zA-Z0-9]([a-zA-Z0-9/-]*[a-zA-Z0-9]))*(:[a-z]+)?,\"\n" +
    "      flow:\n" +
-   "        help: \"Flow activated on this repository\"\n" +
+   "        help: \"Flow applied to this repository: 'trunk' (merge requests to main, fast-forward merge) or 'git-flow' (develop, master, release/* and hotfix/* branches). Repository settings take precedence over the flow settings.\"\n" +
+   "      visibility:\n" +
+   "        help: \"Repository visibility: private, internal or public. By default, the app visibility is used.\"\n" +
+   "      disabled:\n" +
+   "        help: \"true to archive the repository in gitlab. Archived repositories are unarchived when disabled is not true.\"\n" +
+   "      former-name:\n" +
+   "        help: \"Previous name of the repository. Set it when renaming the repository to rename the gitlab project instead of creating a new one.\"\n" +
+   "      default-branch:\n" +
+   "        help: \"Default branch of the repository, connected to the origin remote. If not set, the gitlab default branch is kept ('master' for new repositories).\"\n" +
    "      forjj-workspace-mount:\n" +
    "        help: \"Where the workspace dir is located in the github plugin container.\"\n" +
+   "      protections-management:\n" +
+   "        help: Set 'sync' to manage all repository protected branches and tags, except the default branch protection if not listed. set 'manage' to manage only listed.\n" +
+   "        default: sync\n" +
+   "      wiki:\n" +
+   "        help: \"true to enable the wiki, false to disable it.\"\n" +
+   "      snippets:\n" +
+   "        help: \"true to enable snippets, false to disable them.\"\n" +
+   "      container-registry:\n" +
+   "        help: \"true to enable the container registry, false to disable it.\"\n" +
+   "      lfs:\n" +
+   "        help: \"true to enable Git LFS, false to disable it.\"\n" +
+   "      packages:\n" +
+   "        help: \"true to enable the packages registry, false to disable it.\"\n" +
+   "      pages:\n" +
+   "        help: \"true to enable pages, false to disable them.\"\n" +
+   "      ci-cd:\n" +
+   "        help: \"true to enable CI/CD pipelines, false to disable them.\"\n" +
+   "      merge-method:\n" +
+   "        help: \"Merge requests merge method: merge, rebase_merge or ff (fast-forward).\"\n" +
+   "      squash:\n" +
+   "        help: \"Squash commits when merging: never, always, default_on or default_off.\"\n" +
+   "      remove-source-branch:\n" +
+   "        help: \"true to delete the source branch by default when merging.\"\n" +
+   "      pipelines-must-succeed:\n" +
+   "        help: \"true to allow merge only if the pipeline succeeds.\"\n" +
+   "      discussions-must-be-resolved:\n" +
+   "        help: \"true to allow merge only if all discussions are resolved.\"\n" +
+   "      approvals:\n" +
+   "        help: \"Number of approvals required before merge.\"\n" +
+   "        format-regexp: \"[0-9]+\"\n" +
+   "      approval-rules:\n" +
+   "        help: \"List of approval rules separated by comma. Format: name:approvals[:member+member...]. Group members are prefixed by '@' (ex: security:1:@acme/security+john).\"\n" +
    "      webhooks-management:\n" +
    "        help: Set 'sync' to manage all repository webhooks. set 'manage' to manage only listed.\n" +
    "        default: sync\n" +
@@ -5,11 +5,16 @@ import(
 	"fmt"
 	"path"
 	"log"
+	"net"
+	"net/url"
+	"strings"
 
 	"github.com/forj-oss/goforjj"
 	"github.com/xanzy/go-gitlab"
 )
 
+const defaultServer = "gitlab.com"
+
 //gitlabConnect connect user to gitlab (TODO)
 func (gls *GitlabPlugin) gitlabConnect(server string, ret *goforjj.PluginData) *gitlab.Client {
 	//
@@ -22,13 +27,13 @@ func (gls *GitlabPlugin) gitlabConnect(server string, ret *goforjj.PluginData) *
 	}
 
 	//check connected
-	_, _, err := gls.Client.Users.CurrentUser()
+	user, _, err := gls.Client.Users.CurrentUser()
 	if err != nil {
 		ret.Errorf("Unable to get the owner of the token given.", err)
 		return nil
 	} else {
 		ret.StatusAdd("Connection successful.")
-		//g.user = ...
+		gls.user = user
 	}
 	return gls.Client
 }
@@ -54,23 +59,23 @@ func (req *UpdateReq) InitGroup(gls *GitlabPlugin) (ret bool) {
 //SetGroup ...
 func (gls *GitlabPlugin) SetGroup(fromApp AppInstanceStruct) {
 	if group := fromApp.Group; group == ""{
-		gls.gitlabDeploy.Group = fromApp.ForjjGroup
+		gls.gitlabDeploy.Group = strings.Trim(fromApp.ForjjGroup, "/")
 	} else {
-		gls.gitlabDeploy.Group = group
+		gls.gitlabDeploy.Group = strings.Trim(group, "/")
 	}
 	if group := fromApp.ProductionGroup; group == ""{
-		gls.gitlabDeploy.ProdGroup = fromApp.ForjjGroup
+		gls.gitlabDeploy.ProdGroup = strings.Trim(fromApp.ForjjGroup, "/")
 	} else {
-		gls.gitlabDeploy.ProdGroup = group
+		gls.gitlabDeploy.ProdGroup = strings.Trim(group, "/")
 	}
 	gls.gitlabSource.ProdGroup = gls.gitlabDeploy.ProdGroup
+	if gls.gitlabDeploy.GroupDisplayName == "" {
+		gls.gitlabDeploy.GroupDisplayName = gls.gitlabDeploy.Group
+	}
 }
 
-//ensureGroupExists (TODO)
+//ensureGroupExists ensure the group (full path, ie group/subgroup) exists (created if not) and the user is owner of it.
 func (gls *GitlabPlugin) ensureGroupExists(ret *goforjj.PluginData) (s bool){
-	//Ensure Group exist, todo: if not it is created.
-	//Ensure user is owner (or same).
-
 	if gls.gitlabDeploy.Group == "" {
 		ret.Errorf("Invalid group. The group is empty")
 		return
@@ -79,28 +84,138 @@ func (gls *GitlabPlugin) ensureGroupExists(ret *goforjj.PluginData) (s bool){
 	s = false
 
 	//Try to get group
-	groups, _, err := gls.Client.Groups.SearchGroup(gls.gitlabDeploy.Group)
-	if err != nil{
+	group, resp, err := gls.Client.Groups.GetGroup(gls.gitlabDeploy.Group)
+	if err == nil {
+		//Set GroupID
+		gls.gitlabDeploy.GroupId = group.ID
+		gls.forgeGroup = group
+
+		if !gls.ensureGroupOwner(group, ret) {
+			return
+		}
+
+		log.Printf(ret.StatusAdd("'%s' group access verified", gls.gitlabDeploy.Group))
+		return true
+	}
+	if resp == nil || resp.StatusCode != 404 {
 		log.Printf(ret.Errorf("Unable to get '%s' group information. %s", gls.gitlabDeploy.Group, err))
 		return
 	}
-	for _, group := range groups{
-		if group.Name == gls.gitlabDeploy.Group {
-			//Set GroupID
-			gls.gitlabDeploy.GroupId = group.ID
 
-			//Ensure user is owner (todo)
+	//Need to create the group and missing parents
+	group, err = gls.createGroupPath(gls.gitlabDeploy.Group, ret)
+	if err != nil {
+		log.Printf(ret.Errorf("%s", err))
+		return
+	}
+	gls.gitlabDeploy.GroupId = group.ID
+	gls.forgeGroup = group
+
+	return true
+}
+
+//createGroupPath create missing groups of the full path given, from the top level group to the last subgroup.
+func (gls *GitlabPlugin) createGroupPath(fullPath string, ret *goforjj.PluginData) (group *gitlab.Group, err error) {
+	parentID := 0
+	current := ""
+	names := strings.Split(fullPath, "/")
 
-			log.Printf(ret.StatusAdd("'%s' group access verified", gls.gitlabDeploy.Group))
-			return true
+	for i, name := range names {
+		current = path.Join(current, name)
+
+		found, resp, e := gls.Client.Groups.GetGroup(current)
+		if e == nil {
+			group = found
+			parentID = group.ID
+			continue
+		}
+		if resp == nil || resp.StatusCode != 404 {
+			return nil, fmt.Errorf("Unable to get '%s' group information. %s", current, e)
+		}
+
+		description := name
+		if i == len(names) - 1 {
+			description = gls.gitlabDeploy.GroupDisplayName
+		}
+		if err = checkVisibility(current, gls.groupVisibility(), group); err != nil {
+			return nil, fmt.Errorf("Unable to create '%s' group. %s", current, err)
+		}
+		if gls.planned("group", current, "create", fmt.Sprintf("visibility '%s'", gls.groupVisibility())) {
+			group = &gitlab.Group{Name: name, Path: name, FullPath: current, Visibility: gitlab.Visibility(gls.groupVisibility())}
+			continue
 		}
+		if group, err = gls.createGroup(name, name, description, parentID); err != nil {
+			return nil, fmt.Errorf("Unable to create '%s' group. %s", current, err)
+		}
+		log.Printf(ret.StatusAdd("'%s' group created", current))
+		parentID = group.ID
+	}
+	return
+}
+
+//createGroup create a group (subgroup of parentID if not 0) with the group visibility.
+func (gls *GitlabPlugin) createGroup(name, groupPath, description string, parentID int) (group *gitlab.Group, err error) {
+	groupOptions := &gitlab.CreateGroupOptions{
+		Name:        &name,
+		Path:        &groupPath,
+		Description: &description,
+		Visibility:  gitlab.Visibility(gls.groupVisibility()),
+	}
+	if parentID != 0 {
+		groupOptions.ParentID = &parentID
 	}
 
-	//Need to create the group (todo --> create for user)
-	log.Printf(ret.Errorf("'%s' group need to be created. "))
+	group, _, err = gls.Client.Groups.CreateGroup(groupOptions)
 	return
 }
 
+//groupVisibility return the visibility to apply on groups. private by default.
+func (gls *GitlabPlugin) groupVisibility() gitlab.VisibilityValue {
+	if gls.gitlabDeploy.GroupVisibility == "" {
+		return gitlab.PrivateVisibility
+	}
+	return gitlab.VisibilityValue(gls.gitlabDeploy.GroupVisibility)
+}
+
+//ensureGroupOwner verify the token user is owner of the group given or of one of its parents. Admin users are accepted.
+func (gls *GitlabPlugin) ensureGroupOwner(group *gitlab.Group, ret *goforjj.PluginData) bool {
+	if gls.user == nil {
+		ret.Errorf("Internal Error. The gitlab user was not identified.")
+		return false
+	}
+	if gls.user.IsAdmin {
+		return true
+	}
+
+	for current := group; ; {
+		member, resp, err := gls.Client.GroupMembers.GetGroupMember(current.ID, gls.user.ID)
+		if err != nil && (resp == nil || resp.StatusCode != 404) {
+			log.Printf(ret.Errorf("Unable to get '%s' access to '%s' group. %s", gls.user.Username, current.FullPath, err))
+			return false
+		}
+		if err == nil && member.AccessLevel >= gitlab.OwnerPermission {
+			return true
+		}
+
+		//Owner access can be inherited from a parent group
+		if current.ParentID == 0 {
+			break
+		}
+		if current, _, err = gls.Client.Groups.GetGroup(current.ParentID); err != nil {
+			log.Printf(ret.Errorf("Unable to get '%s' parent group information. %s", group.FullPath, err))
+			return false
+		}
+	}
+
+	log.Printf(ret.Errorf("'%s' is not owner of the '%s' group. Owner access is required to maintain it.", gls.user.Username, group.FullPath))
+	return false
+}
+
+//projectPath return the project full path in the group namespace (group/subgroup/project).
+func (gls *GitlabPlugin) projectPath(name string) string {
+	return gls.gitlabDeploy.Group + "/" + name
+}
+
 //IsNewForge ...
 func (gls *GitlabPlugin) IsNewForge(ret *goforjj.PluginData) (_ bool){
 
@@ -110,8 +225,7 @@ func (gls *GitlabPlugin) IsNewForge(ret *goforjj.PluginData) (_ bool){
 		if !project.Infra{
 			continue
 		}
-		client, _, _ := gls.Client.Users.CurrentUser() //!\\ manage err
-		URLEncPathProject := client.Username + "/" + name
+		URLEncPathProject := gls.projectPath(name)
 		if _, resp, e := ClientProjects.GetProject(URLEncPathProject); e!= nil && resp == nil {
 			ret.Errorf("Unable to identify the infra project. Unknown issue: %s",e)
 			return
@@ -125,32 +239,34 @@ func (gls *GitlabPlugin) IsNewForge(ret *goforjj.PluginData) (_ bool){
 	return
 }
 
-//gitlabSetUrl (TODO: server)
+//gitlabSetUrl set gitlab urls from the server given or from the deploy file in maintain context.
 func (gls *GitlabPlugin) gitlabSetUrl(server string) (err error) {
-	glUrl := ""
-
 	if gls.gitlabSource.Urls == nil {
 		gls.gitlabSource.Urls = make(map[string]string)
 	}
 
 	if !gls.maintainCtxt {
-		if server == "" { // || ? 
-			gls.gitlabSource.Urls["gitlab-base-url"] = "https://gitlab.com/"
-			gls.gitlabSource.Urls["gitlab-url"] = "https://gitlab.com"
-			gls.gitlabSource.Urls["gitlab-ssh"] = "git@gitlab.com:"
-		} else {
-			//set from serveur // ! \\ TODO
-			server = "gitlab.com"
-			glUrl = "https://" + server + "/api/v4/"
-			gls.gitlabSource.Urls["gitlab-url"] = "https://gitlab.com"
-			gls.gitlabSource.Urls["gitlab-ssh"] = "git@gitlab.com:"
+		sshServer := ""
+		if gls.app != nil {
+			sshServer = gls.app.SshServer
+		}
+
+		urls, e := gitlabUrls(server, sshServer)
+		if e != nil {
+			return e
+		}
+		for k, v := range urls {
+			gls.gitlabSource.Urls[k] = v
 		}
+		//maintain only read the deploy file
+		gls.gitlabDeploy.Urls = gls.gitlabSource.Urls
 	} else {
 		//maintain context
 		gls.gitlabSource.Urls = gls.gitlabDeploy.Urls
-		glUrl = gls.gitlabSource.Urls["gitlab-base-url"]
 	}
 
+	glUrl := gls.gitlabSource.Urls["gitlab-api-url"]
+
 	if glUrl == ""{
 		return
 	}
@@ -164,14 +280,96 @@ func (gls *GitlabPlugin) gitlabSetUrl(server string) (err error) {
 	return
 }
 
-//ensureExists (TODO UPDATE and group management)
+//gitlabUrls return gitlab urls from server ([scheme://]host[:port][/path]) and ssh server (host[:port]).
+func gitlabUrls(server, sshServer string) (urls map[string]string, err error) {
+	if server == "" {
+		server = defaultServer
+	}
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse server '%s'. %s", server, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("Invalid server '%s'. Only http and https are supported.", server)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("Invalid server '%s'. The host is empty.", server)
+	}
+
+	glUrl := u.Scheme + "://" + u.Host
+	if prefix := strings.Trim(u.Path, "/"); prefix != "" {
+		glUrl += "/" + prefix
+	}
+
+	//ssh
+	sshHost, sshPort := u.Hostname(), ""
+	if sshServer != "" {
+		if host, port, e := net.SplitHostPort(sshServer); e == nil {
+			sshHost, sshPort = host, port
+		} else {
+			sshHost = sshServer
+		}
+	}
+	glSsh := "git@" + sshHost + ":"
+	if sshPort != "" && sshPort != "22" {
+		glSsh = "ssh://git@" + sshHost + ":" + sshPort + "/"
+	}
+
+	urls = map[string]string{
+		"gitlab-base-url": glUrl + "/",
+		"gitlab-url":      glUrl,
+		"gitlab-api-url":  glUrl + "/api/v4/",
+		"gitlab-ssh":      glSsh,
+	}
+	return
+}
+
+//ensureExists create the project if missing or update changed attributes (TODO group management)
 func (r *ProjectStruct) ensureExists(gls *GitlabPlugin, ret *goforjj.PluginData) error {
 	//test existence
 	clientProjects := gls.Client.Projects
-	//client, _, err := gls.Client.Users.CurrentUser() // Get current user
-	URLEncPathProject := gls.gitlabDeploy.Group + "/" + r.Name // UserName/ProjectName or Group/ProjectName
+	URLEncPathProject := gls.projectPath(r.Name) // Group/ProjectName or Group/SubGroup/ProjectName
+
+	project, _, err := clientProjects.GetProject(URLEncPathProject)
+	if err != nil {
+		//renamed or moved to another group?
+		moved, e := r.findMoved(gls)
+		if e != nil {
+			ret.Errorf("%s", e)
+			return e
+		}
+		if moved != nil {
+			if project, e = r.move(gls, moved, ret); e != nil {
+				return e
+			}
+			err = nil
+		}
+	}
 
-	_, _, err := clientProjects.GetProject(URLEncPathProject)
+	//Disabled projects are archived and not maintained.
+	if r.Disabled {
+		if err != nil {
+			log.Printf(ret.StatusAdd("Repo '%s': disabled, not created", r.Name))
+			return nil
+		}
+		return r.archive(gls, project, "disabled", ret)
+	}
+	if err == nil {
+		if e := r.unarchive(gls, project, ret); e != nil {
+			return e
+		}
+	}
+
+	r.applyFlow()
+
+	if e := checkVisibility(r.Name, gitlab.VisibilityValue(r.Visibility), gls.forgeGroup); e != nil {
+		ret.Errorf("Invalid project '%s'. %s", r.Name, e)
+		return e
+	}
 	
 	if err != nil {
 		//if does'nt exists --> Create
@@ -179,18 +377,62 @@ func (r *ProjectStruct) ensureExists(gls *GitlabPlugin, ret *goforjj.PluginData)
 		projectOptions := &gitlab.CreateProjectOptions{
 			Name: &r.Name,
 			NamespaceID: &gls.gitlabDeploy.GroupId,
+			Description: &r.Description,
+			IssuesEnabled: &r.IssueTracker,
 			ApprovalsBeforeMerge: &ABM, //without: request error because is set to null (restriction SQL: not null)
 		}
-		_, _, e := gls.Client.Projects.CreateProject(projectOptions)
-		if e != nil{
-			ret.Errorf("Unable to create '%s'. %s.", r.Name, e)
+		r.MergeRequests.createOptions(projectOptions)
+		r.Features.createOptions(projectOptions)
+		if r.DefaultBranch != "" {
+			projectOptions.DefaultBranch = &r.DefaultBranch
+		}
+		if r.Visibility != "" {
+			projectOptions.Visibility = gitlab.Visibility(gitlab.VisibilityValue(r.Visibility))
+		}
+		if !gls.planned("project", r.Name, "create", "in '" + gls.gitlabDeploy.Group + "'") {
+			created, _, e := gls.Client.Projects.CreateProject(projectOptions)
+			if e != nil{
+				ret.Errorf("Unable to create '%s'. %s.", r.Name, e)
+				return e
+			}
+			project = created
+			log.Printf(ret.StatusAdd("Repo '%s': created", r.Name))
+		}
+	}
+
+	if project != nil {
+		if e := r.update(gls, project, ret); e != nil {
 			return e
 		}
-		log.Printf(ret.StatusAdd("Repo '%s': created", r.Name))
+		r.setRemotes(project)
+	}
 
-	} else {
-		//Update TODO
-		
+	if e := r.ensureMergeSettings(gls, project, ret); e != nil {
+		return e
+	}
+
+	if e := r.ensureApprovalRules(gls, project, ret); e != nil {
+		return e
+	}
+
+	if e := r.ensureFeatures(gls, project, ret); e != nil {
+		return e
+	}
+
+	if e := r.ensureMembers(gls, project, ret); e != nil {
+		return e
+	}
+
+	if e := r.ensureSharedGroups(gls, project, ret); e != nil {
+		return e
+	}
+
+	if e := r.ensureHooks(gls, project, ret); e != nil {
+		return e
+	}
+
+	if e := r.ensureProtections(gls, project, ret); e != nil {
+		return e
 	}
 	
 	//...
@@ -198,39 +440,81 @@ func (r *ProjectStruct) ensureExists(gls *GitlabPlugin, ret *goforjj.PluginData)
 	return nil
 }
 
+//update edit the existing project with attributes changed only.
+func (r *ProjectStruct) update(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	options, changes := r.changes(project)
+
+	//gitlab refuses a default branch which doesn't exist yet.
+	if branch := options.DefaultBranch; branch != nil {
+		if _, resp, err := gls.Client.Branches.GetBranch(project.ID, *branch); err != nil && resp != nil && resp.StatusCode == 404 {
+			log.Printf(ret.StatusAdd("Repo '%s': branch '%s' not found. Default branch kept to '%s'.", r.Name, *branch, project.DefaultBranch))
+			options.DefaultBranch = nil
+			kept := changes[:0]
+			for _, change := range changes {
+				if change.Attribute != "default branch" {
+					kept = append(kept, change)
+				}
+			}
+			changes = kept
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if gls.plannedChanges("project", r.Name, "update", changes) {
+		return nil
+	}
+
+	if _, _, err := gls.Client.Projects.EditProject(project.ID, options); err != nil {
+		ret.Errorf("Unable to update '%s'. %s.", r.Name, err)
+		return err
+	}
+	for _, change := range changes {
+		log.Printf(ret.StatusAdd("Repo '%s': %s", r.Name, change))
+	}
+	return nil
+}
+
 //projectExists (TODO)
 func (gls *GitlabPlugin) projectsExists(ret *goforjj.PluginData) (err error) {
 	clientProjects := gls.Client.Projects // Projects of user
-	//client, _, err := gls.Client.Users.CurrentUser() // Get current user
-	
+
 	//loop
 	for name, projectData := range gls.gitlabDeploy.Projects{
+		if projectData.Removed{
+			continue
+		}
 
-		URLEncPathProject := gls.gitlabDeploy.Group + "/" + name // client.Username = UserName/ProjectName or gls... = Group/ProjectName
+		URLEncPathProject := gls.projectPath(name) // Group/ProjectName or Group/SubGroup/ProjectName
 		//Get X repo, if find --> err
-		if foundProject, _, e := clientProjects.GetProject(URLEncPathProject); e == nil{
+		foundProject, resp, e := clientProjects.GetProject(URLEncPathProject)
+		switch {
+		case e == nil:
 			if err == nil && name == foundProject.Name {
 				err = fmt.Errorf("Infra projects '%s' already exist in gitlab server.", name)
 			}
-			projectData.exist = true
-			if projectData.remotes == nil{
-				projectData.remotes = make(map[string]goforjj.PluginRepoRemoteUrl)
-				projectData.branchConnect = make(map[string]string)
+			projectData.setRemotes(foundProject)
+		case resp != nil && resp.StatusCode == 404:
+			moved, e := projectData.findMoved(gls)
+			if e != nil {
+				log.Printf("%s. Stored remotes kept.", e)
+				break
 			}
-			projectData.remotes["origin"] = goforjj.PluginRepoRemoteUrl{
-				Ssh: foundProject.SSHURLToRepo,
-				Url: foundProject.HTTPURLToRepo,
+			projectData.Exist = (moved != nil)
+			if moved != nil {
+				//renamed or transferred by maintain
+				log.Printf(ret.StatusAdd("Project '%s' found as '%s'. It will be moved by maintain.", name, moved.PathWithNamespace))
+				projectData.ID = moved.ID
+				projectData.Remotes = map[string]goforjj.PluginRepoRemoteUrl{"origin": gls.DefineRepoUrls(name)}
 			}
-			projectData.branchConnect["master"] = "origin/master"
-		}
-		ret.Repos[name] = goforjj.PluginRepo{ //Project
-			Name: 			projectData.Name,
-			Exist: 			projectData.exist,
-			Remotes: 		projectData.remotes,
-			BranchConnect: 		projectData.branchConnect,
-			//Owner: 		projectData.Owner,
+		default:
+			log.Printf("Unable to get '%s' project information. Stored remotes kept. %s", name, e)
 		}
+		gls.gitlabDeploy.Projects[name] = projectData
 
+		ret.Repos[name] = projectData.pluginRepo()
 	}
 
 	return
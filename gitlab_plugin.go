@@ -25,6 +25,8 @@ type GitlabPlugin struct{
 
 	app			*AppInstanceStruct	//forjfile access
 	Client			*gitlab.Client		//gitlab client ~ api gitlab
+	user			*gitlab.User		//token owner
+	forgeGroup		*gitlab.Group		//gitlab group of the forge (maintain)
 	gitlabSource		GitlabSourceStruct	//urls...
 	gitlabDeploy		GitlabDeployStruct	//
 
@@ -36,6 +38,11 @@ type GitlabPlugin struct{
 	workspaceMount		string
 	maintainCtxt		bool
 	force			bool
+	plan			bool			//report changes without applying them
+	hookTokens		map[string]string	//webhooks secret tokens
+	appliedTokens		map[string]string	//webhooks secret tokens hashes applied (owner/url: hash)
+	tokensApplied		bool			//appliedTokens to save in the workspace
+	planChanges		[]planChange
 
 	newForge		bool
 }
@@ -48,13 +55,22 @@ type GitlabSourceStruct struct{
 
 //GitlabDeployStruct (TODO)
 type GitlabDeployStruct struct{
+	Version				int				`yaml:"version"`	// schema version (deployVersion)
 	goforjj.PluginService						`yaml:",inline"`	//urls
 	Projects			map[string]ProjectStruct				// projects managed in gitlab
+	Groups				map[string]GroupStruct		`yaml:",omitempty"`	// subgroups managed in the group
+	Users				map[string]string		`yaml:",omitempty"`	// group members (name: role)
+	GroupHooks			map[string]WebHookStruct	`yaml:"group-hooks,omitempty"`
+	GroupHookPolicy			string				`yaml:"group-hook-policy,omitempty"`	// sync or manage
+	NoGroupHooks			bool				`yaml:",omitempty"`
 	NoProjects			bool				`yaml:",omitempty"`
+	NoProjectHooks			bool				`yaml:",omitempty"`
+	RemovedProjectsPolicy		string				`yaml:"removed-projects-policy,omitempty"`	// mark, archive, delete or remove
 	ProdGroup			string
 	Group				string
 	GroupDisplayName		string
 	GroupId				int
+	GroupVisibility			string				`yaml:"group-visibility,omitempty"`
 	//...
 }
 
@@ -84,6 +100,10 @@ func (gls *GitlabPlugin) updateFrom(r *UpdateReq, ret *goforjj.PluginData) (stat
 }
 
 func (gls *GitlabPlugin) saveYaml(in interface{}, file string) (Updated bool, _ error) {
+	if deploy, ok := in.(*GitlabDeployStruct); ok {
+		deploy.Version = deployVersion
+	}
+
 	d, err := yaml.Marshal(in)
 	if err != nil {
 		return false, fmt.Errorf("Unable to encode gitlab data in yaml. %s", err)
@@ -110,6 +130,29 @@ func (gls *GitlabPlugin) loadYaml(file string) error {
 		return fmt.Errorf("Unable to load '%s'. %s", file, err)
 	}
 
+	//Migrate the raw document to the current schema version
+	var doc yamlDoc
+	if err = yaml.Unmarshal(d, &doc); err != nil {
+		return fmt.Errorf("Unable to decode gitlab data in yaml. %s", err)
+	}
+	if doc == nil {
+		doc = make(yamlDoc)
+	}
+
+	version := 0
+	if v, found := doc["version"]; found {
+		if version, found = v.(int); !found {
+			return fmt.Errorf("Unable to load '%s'. Invalid version '%v'.", file, v)
+		}
+	}
+	if err = migrateDeploy(doc, version); err != nil {
+		return fmt.Errorf("Unable to load '%s'. %s", file, err)
+	}
+
+	if d, err = yaml.Marshal(doc); err != nil {
+		return fmt.Errorf("Unable to encode gitlab data in yaml. %s", err)
+	}
+
 	err = yaml.Unmarshal(d, &gls.gitlabDeploy)
 
 	if err != nil {
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestGitlabUrls(t *testing.T) {
+	tests := []struct {
+		server    string
+		sshServer string
+		base      string
+		api       string
+		ssh       string
+		fails     bool
+	}{
+		{"", "", "https://gitlab.com/", "https://gitlab.com/api/v4/", "git@gitlab.com:", false},
+		{"gitlab.example.com", "", "https://gitlab.example.com/", "https://gitlab.example.com/api/v4/", "git@gitlab.example.com:", false},
+		{"http://gitlab.local:8080/", "", "http://gitlab.local:8080/", "http://gitlab.local:8080/api/v4/", "git@gitlab.local:", false},
+		{"https://example.com/gitlab/", "", "https://example.com/gitlab/", "https://example.com/gitlab/api/v4/", "git@example.com:", false},
+		{"gitlab.local", "ssh.gitlab.local", "https://gitlab.local/", "https://gitlab.local/api/v4/", "git@ssh.gitlab.local:", false},
+		{"gitlab.local", "gitlab.local:22", "https://gitlab.local/", "https://gitlab.local/api/v4/", "git@gitlab.local:", false},
+		{"gitlab.local", "gitlab.local:2222", "https://gitlab.local/", "https://gitlab.local/api/v4/", "ssh://git@gitlab.local:2222/", false},
+		{"ftp://gitlab.local", "", "", "", "", true},
+		{"https://", "", "", "", "", true},
+	}
+
+	for _, test := range tests {
+		urls, err := gitlabUrls(test.server, test.sshServer)
+		if test.fails {
+			if err == nil {
+				t.Errorf("gitlabUrls(%q, %q): error expected, got %v", test.server, test.sshServer, urls)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("gitlabUrls(%q, %q): unexpected error %s", test.server, test.sshServer, err)
+			continue
+		}
+		if urls["gitlab-base-url"] != test.base {
+			t.Errorf("gitlabUrls(%q, %q): base url '%s', expected '%s'", test.server, test.sshServer, urls["gitlab-base-url"], test.base)
+		}
+		if urls["gitlab-api-url"] != test.api {
+			t.Errorf("gitlabUrls(%q, %q): api url '%s', expected '%s'", test.server, test.sshServer, urls["gitlab-api-url"], test.api)
+		}
+		if urls["gitlab-ssh"] != test.ssh {
+			t.Errorf("gitlabUrls(%q, %q): ssh '%s', expected '%s'", test.server, test.sshServer, urls["gitlab-ssh"], test.ssh)
+		}
+	}
+}
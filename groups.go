@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//GroupStruct forjj group managed as a subgroup of the forge group
+type GroupStruct struct {
+	Name    string
+	Role    string   `yaml:",omitempty"` // guest, reporter, developer, maintainer or owner
+	Members []string `yaml:",omitempty"`
+}
+
+//isValid verify group name and role
+func (g *GroupInstanceStruct) isValid(groupName string, ret *goforjj.PluginData) (valid bool) {
+	if g.Name == "" {
+		ret.Errorf("Invalid group '%s'. Name is empty.", groupName)
+		return
+	}
+	if g.Name != groupName {
+		ret.Errorf("Invalid group '%s'. Name must be equal to '%s'. But the group name is set to '%s'.", groupName, groupName, g.Name)
+		return
+	}
+	if _, found := accessLevels[g.Role]; g.Role != "" && !found {
+		ret.Errorf("Invalid group '%s'. Unknown role '%s'. Valid roles are guest, reporter, developer, maintainer or owner.", groupName, g.Role)
+		return
+	}
+	valid = true
+	return
+}
+
+//set group from the forjj group
+func (g *GroupStruct) set(group *GroupInstanceStruct) *GroupStruct {
+	if g == nil {
+		g = new(GroupStruct)
+	}
+	g.Name = group.Name
+	g.Role = group.Role
+	g.Members = group.Members
+	return g
+}
+
+//SetGroups set subgroups from forjj groups. Invalid groups are ignored.
+func (gls *GitlabPlugin) SetGroups(groups map[string]GroupInstanceStruct, ret *goforjj.PluginData) {
+	gls.gitlabDeploy.Groups = make(map[string]GroupStruct)
+
+	for name, group := range groups {
+		if !group.isValid(name, ret) {
+			ret.StatusAdd("Warning!!! Invalid group '%s' requested. Ignored.", name)
+			continue
+		}
+		groupData := GroupStruct{}
+		groupData.set(&group)
+		gls.gitlabDeploy.Groups[name] = groupData
+	}
+	log.Printf("forjj-gitlab manages %d group(s).", len(gls.gitlabDeploy.Groups))
+}
+
+//members return group members (name: access level) from the group role.
+func (g *GroupStruct) members() (members map[string]string) {
+	role := g.Role
+	if role == "" {
+		role = defaultAccessLevel
+	}
+
+	members = make(map[string]string)
+	for _, member := range g.Members {
+		members[member] = role
+	}
+	return
+}
+
+//ensureExists create the subgroup in the forge group if missing and maintain its members.
+func (g *GroupStruct) ensureExists(gls *GitlabPlugin, ret *goforjj.PluginData) error {
+	fullPath := gls.gitlabDeploy.Group + "/" + g.Name
+
+	group, resp, err := gls.Client.Groups.GetGroup(fullPath)
+	if err != nil {
+		if resp == nil || resp.StatusCode != 404 {
+			ret.Errorf("Unable to get '%s' group information. %s", fullPath, err)
+			return err
+		}
+		group = nil
+		if err = checkVisibility(fullPath, gls.groupVisibility(), gls.forgeGroup); err != nil {
+			ret.Errorf("Unable to create '%s' group. %s", fullPath, err)
+			return err
+		}
+		if !gls.planned("group", fullPath, "create", fmt.Sprintf("visibility '%s'", gls.groupVisibility())) {
+			if group, err = gls.createGroup(g.Name, g.Name, g.Name, gls.gitlabDeploy.GroupId); err != nil {
+				ret.Errorf("Unable to create '%s' group. %s", fullPath, err)
+				return err
+			}
+			log.Printf(ret.StatusAdd("'%s' group created", fullPath))
+		}
+	}
+
+	return gls.ensureGroupMembers(group, fullPath, g.members(), gls.force, ret)
+}
+
+//groupMembers return the group members by user name.
+func (gls *GitlabPlugin) groupMembers(groupID int) (members map[string]*gitlab.GroupMember, err error) {
+	members = make(map[string]*gitlab.GroupMember)
+	options := &gitlab.ListGroupMembersOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		list, resp, e := gls.Client.Groups.ListGroupMembers(groupID, options)
+		if e != nil {
+			return nil, e
+		}
+		for _, member := range list {
+			members[member.Username] = member
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+//ensureGroupMembers add or update group members. Undeclared members are removed if remove is true.
+// group is nil if the group is not created yet (plan mode).
+func (gls *GitlabPlugin) ensureGroupMembers(group *gitlab.Group, fullPath string, members map[string]string, remove bool, ret *goforjj.PluginData) error {
+	current := make(map[string]*gitlab.GroupMember)
+	if group != nil {
+		list, err := gls.groupMembers(group.ID)
+		if err != nil {
+			ret.Errorf("Unable to get '%s' group members. %s", fullPath, err)
+			return err
+		}
+		current = list
+	}
+
+	for name, levelName := range members {
+		level := accessLevels[levelName]
+
+		if member, found := current[name]; found {
+			if member.AccessLevel == level {
+				continue
+			}
+			if gls.plannedChanges("member", fullPath+"/"+name, "update", []attributeChange{changed("access level", accessLevelName(member.AccessLevel), levelName)}) {
+				continue
+			}
+			options := &gitlab.EditGroupMemberOptions{AccessLevel: gitlab.AccessLevel(level)}
+			if _, _, err := gls.Client.GroupMembers.EditGroupMember(group.ID, member.ID, options); err != nil {
+				ret.Errorf("Unable to update '%s' group member '%s'. %s", fullPath, name, err)
+				return err
+			}
+			log.Printf(ret.StatusAdd("Group '%s': member '%s' access level changed to '%s'", fullPath, name, levelName))
+			continue
+		}
+
+		if gls.planned("member", fullPath+"/"+name, "create", fmt.Sprintf("access level '%s'", levelName)) {
+			continue
+		}
+		userID, err := gls.userID(name)
+		if err != nil {
+			ret.Errorf("Unable to add '%s' group member '%s'. %s", fullPath, name, err)
+			return err
+		}
+		options := &gitlab.AddGroupMemberOptions{UserID: &userID, AccessLevel: gitlab.AccessLevel(level)}
+		if _, _, err := gls.Client.GroupMembers.AddGroupMember(group.ID, options); err != nil {
+			ret.Errorf("Unable to add '%s' group member '%s'. %s", fullPath, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Group '%s': member '%s' added as '%s'", fullPath, name, levelName))
+	}
+
+	if !remove {
+		return nil
+	}
+
+	for name, member := range current {
+		if _, declared := members[name]; declared || name == gls.user.Username {
+			continue
+		}
+		if gls.planned("member", fullPath+"/"+name, "delete", "") {
+			continue
+		}
+		if _, err := gls.Client.GroupMembers.RemoveGroupMember(group.ID, member.ID); err != nil {
+			ret.Errorf("Unable to remove '%s' group member '%s'. %s", fullPath, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Group '%s': member '%s' removed", fullPath, name))
+	}
+	return nil
+}
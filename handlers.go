@@ -59,7 +59,8 @@ func panicIfError(w http.ResponseWriter, err error, message string, pars ...inte
 	}
 }
 
-func requestResponse(w http.ResponseWriter, data *goforjj.PluginData, code int) {
+//requestResponse respond with answer, which is data or embeds it.
+func requestResponse(w http.ResponseWriter, data *goforjj.PluginData, answer interface{}, code int) {
 	if data.ErrorMessage != "" {
 		if code == 0 {
 			code = 422 // unprocessable entity
@@ -70,7 +71,7 @@ func requestResponse(w http.ResponseWriter, data *goforjj.PluginData, code int)
 	}
 	w.WriteHeader(code)
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	if err := json.NewEncoder(w).Encode(answer); err != nil {
 		panic(err)
 	}
 
@@ -81,21 +82,40 @@ func doPluginAction(w http.ResponseWriter, r *http.Request,
 	requestDo func(*goforjj.PluginData) int) {
 
 	data := newPluginData()
+	answerPluginAction(w, r, data, data, requestUnmarshal, requestDo)
+}
+
+// answerPluginAction do the plugin action like doPluginAction and respond with answer, which embeds data.
+func answerPluginAction(w http.ResponseWriter, r *http.Request, data *goforjj.PluginData, answer interface{},
+	requestUnmarshal func([]byte) error,
+	requestDo func(*goforjj.PluginData) int) {
+
 	var errCode int
 
 	// Respond to the request in json format except if fatal
-	defer requestResponse(w, data, errCode)
+	defer requestResponse(w, data, answer, errCode)
 
+	if !decodeRequest(r, data, requestUnmarshal) {
+		return
+	}
+
+	errCode = requestDo(data)
+
+	// defer will respond, with data and errCode
+}
+
+// decodeRequest read the json request payload. Return false with the error set in data on failure.
+func decodeRequest(r *http.Request, data *goforjj.PluginData, requestUnmarshal func([]byte) error) bool {
 	body, err := ioutil.ReadAll(io.LimitReader(r.Body, RESTReaderLimit))
 
 	if err != nil {
 		data.Errorf("Unable to read request buffer. %s", err)
-		return
+		return false
 	}
 
 	if contentType, found := contentTypeMatch(r.Header, "application/json"); !found {
 		data.Errorf("Invalid request payload format. Must be 'application/json'. Got %s", contentType)
-		return
+		return false
 	}
 
 	err = requestUnmarshal(body)
@@ -104,12 +124,9 @@ func doPluginAction(w http.ResponseWriter, r *http.Request,
 			err = fmt.Errorf("%s. The plugin reach REST API request buffer limit (%d)", err, RESTReaderLimit)
 		}
 		data.Errorf("Unable to decode json. %s", err)
-		return
+		return false
 	}
-
-	errCode = requestDo(data)
-
-	// defer will respond, with data and errCode
+	return true
 }
 
 // request Handlers
@@ -149,13 +166,14 @@ func Update(w http.ResponseWriter, r *http.Request) {
 // Maintain handler
 func Maintain(w http.ResponseWriter, r *http.Request) {
 	var reqData MaintainReq
+	answer := &planResponse{PluginData: newPluginData()}
 
-	doPluginAction(w, r,
+	answerPluginAction(w, r, answer.PluginData, answer,
 		func(body []byte) error {
 			return json.Unmarshal(body, &reqData)
 		},
 		func(data *goforjj.PluginData) int {
-			return DoMaintain(r, &reqData, data)
+			return DoMaintain(r, &reqData, data, &answer.Plan)
 		})
 }
 
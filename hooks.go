@@ -0,0 +1,434 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+//hookTokensFile workspace file of applied webhook secret tokens hashes. gitlab never returns tokens.
+const hookTokensFile = "gitlab-hook-tokens.yaml"
+
+//hookEvents gitlab webhook events supported.
+var hookEvents = []string{"push", "tag_push", "issues", "confidential_issues", "merge_requests", "note", "job", "pipeline", "wiki_page"}
+
+//WebHookStruct webhook managed in gitlab. Identified in gitlab by its url.
+type WebHookStruct struct {
+	Url      string
+	Events   []string `yaml:",omitempty"`
+	SSLCheck bool     `yaml:"ssl-check"`
+}
+
+//gitlabHook webhook attributes read from gitlab (project and group hooks)
+type gitlabHook struct {
+	ID                       int    `json:"id"`
+	URL                      string `json:"url"`
+	PushEvents               bool   `json:"push_events"`
+	TagPushEvents            bool   `json:"tag_push_events"`
+	IssuesEvents             bool   `json:"issues_events"`
+	ConfidentialIssuesEvents bool   `json:"confidential_issues_events"`
+	MergeRequestsEvents      bool   `json:"merge_requests_events"`
+	NoteEvents               bool   `json:"note_events"`
+	JobEvents                bool   `json:"job_events"`
+	PipelineEvents           bool   `json:"pipeline_events"`
+	WikiPageEvents           bool   `json:"wiki_page_events"`
+	EnableSSLVerification    bool   `json:"enable_ssl_verification"`
+}
+
+//hookOptions webhook attributes sent to gitlab (project and group hooks)
+type hookOptions struct {
+	URL                      *string `json:"url,omitempty"`
+	PushEvents               *bool   `json:"push_events,omitempty"`
+	TagPushEvents            *bool   `json:"tag_push_events,omitempty"`
+	IssuesEvents             *bool   `json:"issues_events,omitempty"`
+	ConfidentialIssuesEvents *bool   `json:"confidential_issues_events,omitempty"`
+	MergeRequestsEvents      *bool   `json:"merge_requests_events,omitempty"`
+	NoteEvents               *bool   `json:"note_events,omitempty"`
+	JobEvents                *bool   `json:"job_events,omitempty"`
+	PipelineEvents           *bool   `json:"pipeline_events,omitempty"`
+	WikiPageEvents           *bool   `json:"wiki_page_events,omitempty"`
+	EnableSSLVerification    *bool   `json:"enable_ssl_verification,omitempty"`
+	Token                    *string `json:"token,omitempty"`
+}
+
+//isValid verify webhook name, url and events
+func (h *WebhooksInstanceStruct) isValid(hookName string) error {
+	if h.Name != hookName {
+		return fmt.Errorf("Name must be equal to '%s'. But the webhook name is set to '%s'.", hookName, h.Name)
+	}
+	if h.Url == "" {
+		return fmt.Errorf("Url is empty.")
+	}
+	for _, event := range splitList(h.Events) {
+		if !inList(event, hookEvents) {
+			return fmt.Errorf("Unknown event '%s'. Valid events are %s.", event, strings.Join(hookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+//setHooks return valid webhooks selected. Invalid webhooks are ignored with a warning.
+// Webhooks are identified by url in gitlab, so 2 webhooks with the same url are refused.
+func setHooks(owner string, webhooks map[string]WebhooksInstanceStruct, selected func(*WebhooksInstanceStruct) bool, ret *goforjj.PluginData) (hooks map[string]WebHookStruct, err error) {
+	urls := make(map[string]string)
+	for hookName, hook := range webhooks {
+		if !selected(&hook) {
+			continue
+		}
+		if e := hook.isValid(hookName); e != nil {
+			log.Printf(ret.StatusAdd("Warning!!! Invalid webhook '%s' requested. Ignored. %s", hookName, e))
+			continue
+		}
+		if other, found := urls[hook.Url]; found {
+			return nil, fmt.Errorf("Webhooks '%s' and '%s' of '%s' have the same url '%s'. Merge their events in one webhook.", other, hookName, owner, hook.Url)
+		}
+		urls[hook.Url] = hookName
+
+		if hooks == nil {
+			hooks = make(map[string]WebHookStruct)
+		}
+		hookData := WebHookStruct{}
+		hookData.set(&hook)
+		hooks[hookName] = hookData
+	}
+	return
+}
+
+//set webhook from the forjj webhook
+func (h *WebHookStruct) set(hook *WebhooksInstanceStruct) *WebHookStruct {
+	if h == nil {
+		h = new(WebHookStruct)
+	}
+	h.Url = hook.Url
+	h.Events = splitList(hook.Events)
+	if len(h.Events) == 0 {
+		h.Events = []string{"push"}
+	}
+	h.SSLCheck = (hook.SslCheck != "false")
+	return h
+}
+
+//SetProjectHooks attach webhooks listing the project in repos. Invalid webhooks are ignored.
+func (gls *GitlabPlugin) SetProjectHooks(name string, project *RepoInstanceStruct, webhooks map[string]WebhooksInstanceStruct, ret *goforjj.PluginData) (err error) {
+	pjt, found := gls.gitlabDeploy.Projects[name]
+	if !found {
+		return
+	}
+
+	pjt.WebhooksManagement = project.WebhooksManagement
+	if pjt.WebhooksManagement == "" {
+		pjt.WebhooksManagement = "sync"
+	}
+
+	pjt.WebHooks, err = setHooks(name, webhooks, func(hook *WebhooksInstanceStruct) bool {
+		return inList(name, splitList(hook.Repos))
+	}, ret)
+	if err != nil {
+		return
+	}
+
+	gls.gitlabDeploy.Projects[name] = pjt
+	return
+}
+
+//events return declared webhook events flags
+func (h *WebHookStruct) events() map[string]bool {
+	events := make(map[string]bool)
+	for _, event := range h.Events {
+		events[event] = true
+	}
+	return events
+}
+
+//events return gitlab webhook events flags
+func (h *gitlabHook) events() map[string]bool {
+	return map[string]bool{
+		"push":                h.PushEvents,
+		"tag_push":            h.TagPushEvents,
+		"issues":              h.IssuesEvents,
+		"confidential_issues": h.ConfidentialIssuesEvents,
+		"merge_requests":      h.MergeRequestsEvents,
+		"note":                h.NoteEvents,
+		"job":                 h.JobEvents,
+		"pipeline":            h.PipelineEvents,
+		"wiki_page":           h.WikiPageEvents,
+	}
+}
+
+//changes return webhook attributes changed in gitlab.
+func (h *WebHookStruct) changes(hook *gitlabHook) (changes []attributeChange) {
+	events, live := h.events(), hook.events()
+	for _, event := range hookEvents {
+		if events[event] != live[event] {
+			changes = append(changes, changed(event+" event", live[event], events[event]))
+		}
+	}
+	if hook.EnableSSLVerification != h.SSLCheck {
+		changes = append(changes, changed("ssl check", hook.EnableSSLVerification, h.SSLCheck))
+	}
+	return
+}
+
+//options return gitlab webhook options. The token is sent if not empty.
+func (h *WebHookStruct) options(token string) *hookOptions {
+	events := h.events()
+	enabled := func(event string) *bool {
+		return gitlab.Bool(events[event])
+	}
+
+	options := &hookOptions{
+		URL:                      &h.Url,
+		PushEvents:               enabled("push"),
+		TagPushEvents:            enabled("tag_push"),
+		IssuesEvents:             enabled("issues"),
+		ConfidentialIssuesEvents: enabled("confidential_issues"),
+		MergeRequestsEvents:      enabled("merge_requests"),
+		NoteEvents:               enabled("note"),
+		JobEvents:                enabled("job"),
+		PipelineEvents:           enabled("pipeline"),
+		WikiPageEvents:           enabled("wiki_page"),
+		EnableSSLVerification:    gitlab.Bool(h.SSLCheck),
+	}
+	if token != "" {
+		options.Token = &token
+	}
+	return options
+}
+
+//apiRequest send a gitlab API request and decode the answer in v if not nil.
+// Used for gitlab API not implemented by go-gitlab.
+func (gls *GitlabPlugin) apiRequest(method, apiPath string, opt interface{}, v interface{}) error {
+	req, err := gls.Client.NewRequest(method, apiPath, opt, nil)
+	if err != nil {
+		return err
+	}
+	_, err = gls.Client.Do(req, v)
+	return err
+}
+
+//errorStatus return the http status of a gitlab api error, or 0.
+func errorStatus(err error) int {
+	if e, ok := err.(*gitlab.ErrorResponse); ok && e.Response != nil {
+		return e.Response.StatusCode
+	}
+	return 0
+}
+
+//listHooks return webhooks of hooksPath (projects/<id>/hooks or groups/<id>/hooks) by url.
+func (gls *GitlabPlugin) listHooks(hooksPath string) (hooks map[string]*gitlabHook, err error) {
+	var list []*gitlabHook
+	options := &gitlab.ListOptions{PerPage: 100}
+	hooks = make(map[string]*gitlabHook)
+	for {
+		list = nil
+		req, e := gls.Client.NewRequest("GET", hooksPath, options, nil)
+		if e != nil {
+			return nil, e
+		}
+		resp, e := gls.Client.Do(req, &list)
+		if e != nil {
+			return nil, e
+		}
+		for _, hook := range list {
+			hooks[hook.URL] = hook
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+//tokenHash return the hash of a webhook secret token.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+//hookTokensPath return the workspace file of applied webhook tokens. Empty without workspace.
+func (gls *GitlabPlugin) hookTokensPath() string {
+	if gls.workspaceMount == "" {
+		return ""
+	}
+	return path.Join(gls.workspaceMount, gls.instance, hookTokensFile)
+}
+
+//loadHookTokens read applied webhook tokens hashes (owner/url: hash) from the workspace.
+// Unknown tokens are sent again.
+func (gls *GitlabPlugin) loadHookTokens() {
+	gls.appliedTokens = make(map[string]string)
+	file := gls.hookTokensPath()
+	if file == "" {
+		return
+	}
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(d, &gls.appliedTokens); err != nil {
+		log.Printf("Unable to decode '%s'. Webhooks secret tokens will be sent again. %s", file, err)
+		gls.appliedTokens = make(map[string]string)
+	}
+}
+
+//saveHookTokens save applied webhook tokens hashes in the workspace, if some were applied.
+func (gls *GitlabPlugin) saveHookTokens(ret *goforjj.PluginData) {
+	file := gls.hookTokensPath()
+	if !gls.tokensApplied || file == "" {
+		return
+	}
+	d, err := yaml.Marshal(gls.appliedTokens)
+	if err == nil {
+		if err = os.MkdirAll(path.Dir(file), 0755); err == nil {
+			err = ioutil.WriteFile(file, d, 0600)
+		}
+	}
+	if err != nil {
+		log.Printf(ret.StatusAdd("Warning!!! Unable to save '%s'. Webhooks secret tokens will be sent again. %s", file, err))
+	}
+}
+
+//tokenApplied record the token applied to the webhook (owner/url). An empty token forgets it.
+func (gls *GitlabPlugin) tokenApplied(tokenKey, token string) {
+	if token == "" {
+		if _, found := gls.appliedTokens[tokenKey]; !found {
+			return
+		}
+		delete(gls.appliedTokens, tokenKey)
+	} else {
+		gls.appliedTokens[tokenKey] = tokenHash(token)
+	}
+	gls.tokensApplied = true
+}
+
+//ensureHooks create or update declared webhooks of hooksPath (projects/<id>/hooks or groups/<id>/hooks).
+// With 'sync' policy, undeclared webhooks are deleted. hooksPath is empty if the owner is not created yet (plan mode).
+func (gls *GitlabPlugin) ensureHooks(kind, owner, hooksPath string, hooks map[string]WebHookStruct, policy string, ret *goforjj.PluginData) error {
+	current := make(map[string]*gitlabHook)
+	if hooksPath != "" {
+		list, err := gls.listHooks(hooksPath)
+		if err != nil {
+			ret.Errorf("Unable to get '%s' webhooks. %s", owner, err)
+			return err
+		}
+		current = list
+	}
+
+	if gls.appliedTokens == nil {
+		gls.loadHookTokens()
+	}
+
+	declared := make(map[string]bool)
+	for name, hook := range hooks {
+		declared[hook.Url] = true
+		token := gls.hookTokens[name]
+		tokenKey := owner + "/" + hook.Url
+
+		if live, found := current[hook.Url]; found {
+			changes := hook.changes(live)
+			//an unknown token (no applied hash in this workspace) is sent once, but not reported as a change.
+			applied := gls.appliedTokens[tokenKey]
+			resend := (token != "" && applied != tokenHash(token))
+			if resend && applied != "" {
+				changes = append(changes, changed("secret token", "outdated", "declared"))
+			}
+			if len(changes) == 0 && (!resend || gls.plan) {
+				continue
+			}
+			if len(changes) > 0 && gls.plannedChanges(kind, owner+"/"+name, "update", changes) {
+				continue
+			}
+			if err := gls.apiRequest("PUT", fmt.Sprintf("%s/%d", hooksPath, live.ID), hook.options(token), nil); err != nil {
+				ret.Errorf("Unable to update '%s' webhook '%s'. %s", owner, name, err)
+				return err
+			}
+			gls.tokenApplied(tokenKey, token)
+			if len(changes) == 0 {
+				log.Printf("'%s': webhook '%s' secret token sent.", owner, name)
+				continue
+			}
+			log.Printf(ret.StatusAdd("'%s': webhook '%s' updated", owner, name))
+			continue
+		}
+
+		if gls.planned(kind, owner+"/"+name, "create", "url '"+hook.Url+"'") {
+			continue
+		}
+		if err := gls.apiRequest("POST", hooksPath, hook.options(token), nil); err != nil {
+			ret.Errorf("Unable to create '%s' webhook '%s'. %s", owner, name, err)
+			return err
+		}
+		gls.tokenApplied(tokenKey, token)
+		log.Printf(ret.StatusAdd("'%s': webhook '%s' created", owner, name))
+	}
+
+	if policy != "sync" {
+		return nil
+	}
+
+	for hookUrl, live := range current {
+		if declared[hookUrl] {
+			continue
+		}
+		if gls.planned(kind, owner+"/"+hookUrl, "delete", "") {
+			continue
+		}
+		if err := gls.apiRequest("DELETE", fmt.Sprintf("%s/%d", hooksPath, live.ID), nil, nil); err != nil {
+			ret.Errorf("Unable to delete '%s' webhook '%s'. %s", owner, hookUrl, err)
+			return err
+		}
+		gls.tokenApplied(owner+"/"+hookUrl, "")
+		log.Printf(ret.StatusAdd("'%s': webhook '%s' deleted", owner, hookUrl))
+	}
+	return nil
+}
+
+//ensureHooks maintain project webhooks (not managed if projects webhooks are disabled)
+func (r *ProjectStruct) ensureHooks(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	if gls.gitlabDeploy.NoProjectHooks {
+		return nil
+	}
+
+	hooksPath := ""
+	if project != nil {
+		hooksPath = fmt.Sprintf("projects/%d/hooks", project.ID)
+	}
+	return gls.ensureHooks("project hook", r.Name, hooksPath, r.WebHooks, r.WebhooksManagement, ret)
+}
+
+//SetGroupHooks set group webhooks and policy from the app. Invalid webhooks are ignored.
+func (gls *GitlabPlugin) SetGroupHooks(webhooks map[string]WebhooksInstanceStruct, ret *goforjj.PluginData) (err error) {
+	gls.gitlabDeploy.NoGroupHooks = (gls.app.OrganizationWebhooksDisabled == "true")
+	gls.gitlabDeploy.GroupHookPolicy = gls.app.OrgHookPolicy
+	if gls.gitlabDeploy.GroupHookPolicy != "manage" {
+		gls.gitlabDeploy.GroupHookPolicy = "sync"
+	}
+
+	gls.gitlabDeploy.GroupHooks, err = setHooks(gls.gitlabDeploy.Group, webhooks, func(hook *WebhooksInstanceStruct) bool {
+		return hook.Group == "true"
+	}, ret)
+	return
+}
+
+//ensureGroupHooks maintain the group webhooks (not managed if group webhooks are disabled)
+func (gls *GitlabPlugin) ensureGroupHooks(ret *goforjj.PluginData) error {
+	if gls.gitlabDeploy.NoGroupHooks {
+		log.Printf(ret.StatusAdd("Group webhooks not managed."))
+		return nil
+	}
+
+	hooksPath := ""
+	if gls.gitlabDeploy.GroupId != 0 {
+		hooksPath = fmt.Sprintf("groups/%d/hooks", gls.gitlabDeploy.GroupId)
+	}
+	return gls.ensureHooks("group hook", gls.gitlabDeploy.Group, hooksPath, gls.gitlabDeploy.GroupHooks, gls.gitlabDeploy.GroupHookPolicy, ret)
+}
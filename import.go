@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//gitlabImportSettings project settings not implemented by go-gitlab
+type gitlabImportSettings struct {
+	gitlabMergeSettings
+	gitlabFeatureSettings
+}
+
+//groupProjects return the projects of the group given (subgroups projects excluded).
+func (gls *GitlabPlugin) groupProjects(groupID int) (projects []*gitlab.Project, err error) {
+	options := &gitlab.ListGroupProjectsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		list, resp, e := gls.Client.Groups.ListGroupProjects(groupID, options)
+		if e != nil {
+			return nil, e
+		}
+		projects = append(projects, list...)
+		if resp.NextPage == 0 {
+			return
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+//importProjects adopt the group projects not declared in the Forjfile into deploy data, with their gitlab settings.
+// So, the first maintain does not change them. Declared projects keep their Forjfile settings: the changes maintain
+// will apply on them are reported.
+func (gls *GitlabPlugin) importProjects(ret *goforjj.PluginData) error {
+	group, resp, err := gls.Client.Groups.GetGroup(gls.gitlabDeploy.Group)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			log.Printf(ret.StatusAdd("Import: '%s' group not found. No project to import.", gls.gitlabDeploy.Group))
+			return nil
+		}
+		return fmt.Errorf("Unable to get '%s' group information. %s", gls.gitlabDeploy.Group, err)
+	}
+
+	projects, err := gls.groupProjects(group.ID)
+	if err != nil {
+		return fmt.Errorf("Unable to get '%s' group projects. %s", gls.gitlabDeploy.Group, err)
+	}
+
+	for _, project := range projects {
+		if project.Namespace != nil && project.Namespace.ID != group.ID {
+			continue
+		}
+		if declared, found := gls.gitlabDeploy.Projects[project.Path]; found {
+			live, err := gls.importProject(project)
+			if err != nil {
+				return fmt.Errorf("Unable to import '%s'. %s", project.PathWithNamespace, err)
+			}
+			differences := declared.importDifferences(project, live)
+			if len(differences) == 0 {
+				log.Printf(ret.StatusAdd("Import: project '%s' declared in the Forjfile. It matches gitlab.", project.Path))
+				continue
+			}
+			log.Printf(ret.StatusAdd("Import: project '%s' declared in the Forjfile. Forjfile settings are kept. Maintain will change:", project.Path))
+			for _, difference := range differences {
+				log.Printf(ret.StatusAdd("Import: project '%s': %s", project.Path, difference))
+			}
+			continue
+		}
+		if gls.gitlabDeploy.NoProjects {
+			continue
+		}
+
+		projectData, err := gls.importProject(project)
+		if err != nil {
+			return fmt.Errorf("Unable to import '%s'. %s", project.PathWithNamespace, err)
+		}
+		gls.gitlabDeploy.Projects[project.Path] = projectData
+		log.Printf(ret.StatusAdd("Import: project '%s' imported. Add it to your Forjfile to keep it managed.", project.Path))
+	}
+	return nil
+}
+
+//importProject return the project data from gitlab settings, members, webhooks and protections.
+func (gls *GitlabPlugin) importProject(project *gitlab.Project) (r ProjectStruct, err error) {
+	r = ProjectStruct{
+		Name:                  project.Path,
+		Description:           project.Description,
+		Disabled:              project.Archived,
+		IssueTracker:          project.IssuesEnabled,
+		Visibility:            string(project.Visibility),
+		DefaultBranch:         project.DefaultBranch,
+		WebhooksManagement:    "sync",
+		ProtectionsManagement: "sync",
+		Role:                  "code",
+		Owner:                 gls.gitlabDeploy.Group,
+		Imported:              true,
+	}
+	r.setRemotes(project)
+
+	settings := new(gitlabImportSettings)
+	if err = gls.apiRequest("GET", fmt.Sprintf("projects/%d", project.ID), nil, settings); err != nil {
+		return
+	}
+
+	r.MergeRequests = MergeRequestsStruct{
+		Method:                    project.MergeMethod,
+		Squash:                    settings.SquashOption,
+		RemoveSourceBranch:        settings.RemoveSourceBranchAfterMerge,
+		PipelinesMustSucceed:      gitlab.Bool(project.OnlyAllowMergeIfPipelineSucceeds),
+		DiscussionsMustBeResolved: gitlab.Bool(project.OnlyAllowMergeIfAllDiscussionsAreResolved),
+		Approvals:                 gitlab.Int(project.ApprovalsBeforeMerge),
+	}
+	r.Features = FeaturesStruct{
+		Wiki:              gitlab.Bool(project.WikiEnabled),
+		Snippets:          gitlab.Bool(project.SnippetsEnabled),
+		ContainerRegistry: gitlab.Bool(project.ContainerRegistryEnabled),
+		LFS:               gitlab.Bool(project.LFSEnabled),
+		Packages:          settings.PackagesEnabled,
+		CICD:              gitlab.Bool(project.JobsEnabled),
+	}
+	if settings.PagesAccessLevel != "" {
+		r.Features.Pages = gitlab.Bool(settings.PagesAccessLevel != "disabled")
+	}
+
+	if err = r.importMembers(gls, project); err != nil {
+		return
+	}
+	if err = r.importHooks(gls, project); err != nil {
+		return
+	}
+	if r.ProtectedBranches, err = gls.importProtections(fmt.Sprintf("projects/%d/protected_branches", project.ID), false); err != nil {
+		return
+	}
+	r.ProtectedTags, err = gls.importProtections(fmt.Sprintf("projects/%d/protected_tags", project.ID), true)
+	return
+}
+
+//importDifferences return the changes maintain will apply on the gitlab project from the declared project.
+// live is the project imported from gitlab.
+func (r *ProjectStruct) importDifferences(project *gitlab.Project, live ProjectStruct) (differences []string) {
+	_, changes := r.changes(project)
+	for _, change := range changes {
+		differences = append(differences, change.String())
+	}
+
+	for name, role := range r.Users {
+		if live.Users[name] != role {
+			differences = append(differences, changed(fmt.Sprintf("member '%s' access", name), live.Users[name], role).String())
+		}
+	}
+	for name, role := range r.Groups {
+		if live.Groups[name] != role {
+			differences = append(differences, changed(fmt.Sprintf("shared group '%s' access", name), live.Groups[name], role).String())
+		}
+	}
+
+	if r.WebhooksManagement == "sync" {
+		declared := make(map[string]bool)
+		for _, hook := range r.WebHooks {
+			declared[hook.Url] = true
+		}
+		for _, hook := range live.WebHooks {
+			if !declared[hook.Url] {
+				differences = append(differences, fmt.Sprintf("webhook '%s' removed", hook.Url))
+			}
+		}
+	}
+	if r.ProtectionsManagement == "sync" {
+		for pattern := range live.ProtectedBranches {
+			//gitlab default branch protection kept if not declared.
+			if _, declared := r.ProtectedBranches[pattern]; !declared && pattern != project.DefaultBranch {
+				differences = append(differences, fmt.Sprintf("protected branch '%s' removed", pattern))
+			}
+		}
+		for pattern := range live.ProtectedTags {
+			if _, declared := r.ProtectedTags[pattern]; !declared {
+				differences = append(differences, fmt.Sprintf("protected tag '%s' removed", pattern))
+			}
+		}
+	}
+	sort.Strings(differences)
+	return
+}
+
+//importMembers set project members and shared groups from gitlab. The token user is ignored.
+func (r *ProjectStruct) importMembers(gls *GitlabPlugin, project *gitlab.Project) error {
+	members, err := gls.projectMembers(project.ID)
+	if err != nil {
+		return fmt.Errorf("Unable to get members. %s", err)
+	}
+	for name, member := range members {
+		if gls.user != nil && name == gls.user.Username {
+			continue
+		}
+		if r.Users == nil {
+			r.Users = make(map[string]string)
+		}
+		r.Users[name] = accessLevelName(member.AccessLevel)
+	}
+
+	for _, shared := range project.SharedWithGroups {
+		group, _, err := gls.Client.Groups.GetGroup(shared.GroupID)
+		if err != nil {
+			return fmt.Errorf("Unable to get '%s' shared group information. %s", shared.GroupName, err)
+		}
+		if r.Groups == nil {
+			r.Groups = make(map[string]string)
+		}
+		r.Groups[group.FullPath] = accessLevelName(gitlab.AccessLevelValue(shared.GroupAccessLevel))
+	}
+	return nil
+}
+
+//importHooks set project webhooks from gitlab. Webhooks are named from their gitlab ID.
+func (r *ProjectStruct) importHooks(gls *GitlabPlugin, project *gitlab.Project) error {
+	hooks, err := gls.listHooks(fmt.Sprintf("projects/%d/hooks", project.ID))
+	if err != nil {
+		return fmt.Errorf("Unable to get webhooks. %s", err)
+	}
+	for hookUrl, hook := range hooks {
+		if r.WebHooks == nil {
+			r.WebHooks = make(map[string]WebHookStruct)
+		}
+		r.WebHooks[fmt.Sprintf("hook-%d", hook.ID)] = importedHook(hookUrl, hook)
+	}
+	return nil
+}
+
+//importedHook return the webhook data of a gitlab webhook.
+func importedHook(hookUrl string, hook *gitlabHook) WebHookStruct {
+	hookData := WebHookStruct{Url: hookUrl, SSLCheck: hook.EnableSSLVerification}
+	events := hook.events()
+	for _, event := range hookEvents {
+		if events[event] {
+			hookData.Events = append(hookData.Events, event)
+		}
+	}
+	return hookData
+}
+
+//importProtections return protected branches or tags of protectionsPath from gitlab by name pattern.
+func (gls *GitlabPlugin) importProtections(protectionsPath string, tag bool) (protections map[string]ProtectionStruct, err error) {
+	list, err := gls.listProtections(protectionsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get protections. %s", err)
+	}
+
+	for pattern, live := range list {
+		protection := ProtectionStruct{}
+		access := live.PushAccessLevels
+		if tag {
+			access = live.CreateAccessLevels
+		}
+		level, users, groups := accessSummary(access)
+		protection.Push = protectionLevelName(level)
+		if !tag {
+			level, _, _ = accessSummary(live.MergeAccessLevels)
+			protection.Merge = protectionLevelName(level)
+			protection.Unprotect = defaultProtectionLevel
+			if len(live.UnprotectAccessLevels) > 0 {
+				level, _, _ = accessSummary(live.UnprotectAccessLevels)
+				protection.Unprotect = protectionLevelName(level)
+			}
+			protection.CodeOwnerApproval = live.CodeOwnerApprovalRequired
+		}
+
+		for id := range users {
+			user, _, e := gls.Client.Users.GetUser(id)
+			if e != nil {
+				return nil, fmt.Errorf("Unable to get user %d information. %s", id, e)
+			}
+			protection.Users = append(protection.Users, user.Username)
+		}
+		for id := range groups {
+			group, _, e := gls.Client.Groups.GetGroup(id)
+			if e != nil {
+				return nil, fmt.Errorf("Unable to get group %d information. %s", id, e)
+			}
+			protection.Groups = append(protection.Groups, group.FullPath)
+		}
+		sort.Strings(protection.Users)
+		sort.Strings(protection.Groups)
+
+		if protections == nil {
+			protections = make(map[string]ProtectionStruct)
+		}
+		protections[pattern] = protection
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//removedProjectsPolicies policies applied to projects removed from the Forjfile.
+var removedProjectsPolicies = []string{"mark", "archive", "delete", "remove"}
+
+//SetRemovedProjectsPolicy validate and set the policy applied to projects removed from the Forjfile. 'mark' by default.
+func (gls *GitlabPlugin) SetRemovedProjectsPolicy() error {
+	policy := gls.app.RemovedProjectsPolicy
+	if policy == "" {
+		policy = "mark"
+	}
+	if !inList(policy, removedProjectsPolicies) {
+		return fmt.Errorf("Invalid removed-projects-policy '%s'. Valid policies are mark, archive, delete or remove.", policy)
+	}
+	gls.gitlabDeploy.RemovedProjectsPolicy = policy
+	return nil
+}
+
+//archive archive the gitlab project. project is nil if not created yet (plan mode).
+func (r *ProjectStruct) archive(gls *GitlabPlugin, project *gitlab.Project, reason string, ret *goforjj.PluginData) error {
+	if project == nil || project.Archived {
+		return nil
+	}
+	if gls.planned("project", r.Name, "archive", reason) {
+		return nil
+	}
+	if _, _, err := gls.Client.Projects.ArchiveProject(project.ID); err != nil {
+		ret.Errorf("Unable to archive '%s'. %s.", r.Name, err)
+		return err
+	}
+	project.Archived = true
+	log.Printf(ret.StatusAdd("Repo '%s': archived (%s)", r.Name, reason))
+	return nil
+}
+
+//unarchive unarchive the gitlab project.
+func (r *ProjectStruct) unarchive(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	if project == nil || !project.Archived {
+		return nil
+	}
+	if gls.planned("project", r.Name, "unarchive", "enabled") {
+		return nil
+	}
+	if _, _, err := gls.Client.Projects.UnarchiveProject(project.ID); err != nil {
+		ret.Errorf("Unable to unarchive '%s'. %s.", r.Name, err)
+		return err
+	}
+	project.Archived = false
+	log.Printf(ret.StatusAdd("Repo '%s': unarchived", r.Name))
+	return nil
+}
+
+//ensureRemoved apply the removed projects policy on a project removed from the Forjfile.
+// 'delete' requires force. Without force, the deletion is only reported.
+func (r *ProjectStruct) ensureRemoved(gls *GitlabPlugin, ret *goforjj.PluginData) error {
+	policy := gls.gitlabDeploy.RemovedProjectsPolicy
+	if policy != "archive" && policy != "delete" {
+		log.Printf(ret.StatusAdd("Project ignored: %s - removed from the Forjfile", r.Name))
+		return nil
+	}
+
+	project, resp, err := gls.Client.Projects.GetProject(gls.projectPath(r.Name))
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			log.Printf("Project '%s' removed from the Forjfile is not in gitlab.", r.Name)
+			return nil
+		}
+		ret.Errorf("Unable to get '%s' project information. %s", r.Name, err)
+		return err
+	}
+
+	if policy == "archive" {
+		return r.archive(gls, project, "removed from the Forjfile", ret)
+	}
+
+	if !gls.force {
+		log.Printf(ret.StatusAdd("Repo '%s': removed from the Forjfile. Use --force to delete it from gitlab.", r.Name))
+		return nil
+	}
+	if gls.planned("project", r.Name, "delete", "removed from the Forjfile") {
+		return nil
+	}
+	if _, err := gls.Client.Projects.DeleteProject(project.ID); err != nil {
+		ret.Errorf("Unable to delete '%s'. %s.", r.Name, err)
+		return err
+	}
+	log.Printf(ret.StatusAdd("Repo '%s': deleted (removed from the Forjfile)", r.Name))
+	return nil
+}
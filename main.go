@@ -16,6 +16,10 @@ func main() {
 	switch kingpin.MustParse(cliApp.App.Parse(os.Args[1:])) {
 	case "service start":
 		cliApp.start_server()
+	case "export":
+		cliApp.export()
+	case "drift":
+		cliApp.drift()
 	default:
 		kingpin.Usage()
 	}
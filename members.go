@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+const defaultAccessLevel = "developer"
+
+//accessLevels map forjj access level names to gitlab access levels
+var accessLevels = map[string]gitlab.AccessLevelValue{
+	"guest":      gitlab.GuestPermissions,
+	"reporter":   gitlab.ReporterPermissions,
+	"developer":  gitlab.DeveloperPermissions,
+	"maintainer": gitlab.MasterPermissions,
+	"owner":      gitlab.OwnerPermission,
+}
+
+//accessLevelName return the forjj access level name of a gitlab access level.
+func accessLevelName(level gitlab.AccessLevelValue) string {
+	for name, value := range accessLevels {
+		if value == level {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d", level)
+}
+
+//parseMembers return members (name: access level) from a list separated by comma: [+-]name[:level]
+// '-' members are returned as removed. 'owner' is not a valid project access level.
+func parseMembers(list string) (members map[string]string, removed []string, err error) {
+	members = make(map[string]string)
+	for _, member := range strings.Split(list, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+
+		remove := strings.HasPrefix(member, "-")
+		member = strings.TrimLeft(member, "+-")
+
+		level := defaultAccessLevel
+		if i := strings.Index(member, ":"); i >= 0 {
+			member, level = member[:i], strings.ToLower(member[i+1:])
+		}
+		if member == "" {
+			return nil, nil, fmt.Errorf("Invalid member in '%s'. Name is empty.", list)
+		}
+		if _, found := accessLevels[level]; !found || level == "owner" {
+			return nil, nil, fmt.Errorf("Invalid access level '%s' for '%s'. Valid levels are guest, reporter, developer or maintainer.", level, member)
+		}
+
+		if remove {
+			delete(members, member)
+			removed = append(removed, member)
+			continue
+		}
+		members[member] = level
+	}
+	removed = removedMembers(members, removed, nil, nil)
+	return
+}
+
+//removedMembers return the sorted members to revoke: removed ones and previous ones, not declared anymore.
+func removedMembers(declared map[string]string, removed []string, previous map[string]string, previousRemoved []string) (list []string) {
+	revoke := make(map[string]bool)
+	for _, name := range append(removed, previousRemoved...) {
+		revoke[name] = true
+	}
+	for name := range previous {
+		revoke[name] = true
+	}
+	for name := range revoke {
+		if _, found := declared[name]; !found {
+			list = append(list, name)
+		}
+	}
+	sort.Strings(list)
+	return
+}
+
+//userID return the gitlab user ID of the user name given.
+func (gls *GitlabPlugin) userID(name string) (id int, err error) {
+	users, _, err := gls.Client.Users.ListUsers(&gitlab.ListUsersOptions{Username: &name})
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get '%s' user information. %s", name, err)
+	}
+	for _, user := range users {
+		if user.Username == name {
+			return user.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("User '%s' not found in gitlab.", name)
+}
+
+//projectMembers return the project members by user name.
+func (gls *GitlabPlugin) projectMembers(projectID int) (members map[string]*gitlab.ProjectMember, err error) {
+	members = make(map[string]*gitlab.ProjectMember)
+	options := &gitlab.ListProjectMembersOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		list, resp, e := gls.Client.ProjectMembers.ListProjectMembers(projectID, options)
+		if e != nil {
+			return nil, e
+		}
+		for _, member := range list {
+			members[member.Username] = member
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+//ensureMembers add or update project members from Users. Members are not managed if Users is not set.
+// RemovedUsers members are revoked only with force.
+func (r *ProjectStruct) ensureMembers(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	if r.Users == nil {
+		return nil
+	}
+
+	current := make(map[string]*gitlab.ProjectMember)
+	if project != nil {
+		members, err := gls.projectMembers(project.ID)
+		if err != nil {
+			ret.Errorf("Unable to get '%s' members. %s", r.Name, err)
+			return err
+		}
+		current = members
+	}
+
+	for name, levelName := range r.Users {
+		level := accessLevels[levelName]
+
+		if member, found := current[name]; found {
+			if member.AccessLevel == level {
+				continue
+			}
+			if gls.plannedChanges("member", r.Name+"/"+name, "update", []attributeChange{changed("access level", accessLevelName(member.AccessLevel), levelName)}) {
+				continue
+			}
+			options := &gitlab.EditProjectMemberOptions{AccessLevel: gitlab.AccessLevel(level)}
+			if _, _, err := gls.Client.ProjectMembers.EditProjectMember(project.ID, member.ID, options); err != nil {
+				ret.Errorf("Unable to update '%s' member '%s'. %s", r.Name, name, err)
+				return err
+			}
+			log.Printf(ret.StatusAdd("Repo '%s': member '%s' access level changed to '%s'", r.Name, name, levelName))
+			continue
+		}
+
+		if gls.planned("member", r.Name+"/"+name, "create", fmt.Sprintf("access level '%s'", levelName)) {
+			continue
+		}
+		userID, err := gls.userID(name)
+		if err != nil {
+			ret.Errorf("Unable to add '%s' member '%s'. %s", r.Name, name, err)
+			return err
+		}
+		options := &gitlab.AddProjectMemberOptions{UserID: &userID, AccessLevel: gitlab.AccessLevel(level)}
+		if _, _, err := gls.Client.ProjectMembers.AddProjectMember(project.ID, options); err != nil {
+			ret.Errorf("Unable to add '%s' member '%s'. %s", r.Name, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': member '%s' added as '%s'", r.Name, name, levelName))
+	}
+
+	if !gls.force {
+		return nil
+	}
+
+	for _, name := range r.RemovedUsers {
+		member, found := current[name]
+		if !found || name == gls.user.Username {
+			continue
+		}
+		if gls.planned("member", r.Name+"/"+name, "delete", "") {
+			continue
+		}
+		if _, err := gls.Client.ProjectMembers.DeleteProjectMember(project.ID, member.ID); err != nil {
+			ret.Errorf("Unable to remove '%s' member '%s'. %s", r.Name, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': member '%s' removed", r.Name, name))
+	}
+	return nil
+}
+
+//groupID return the gitlab group ID of the group full path given.
+func (gls *GitlabPlugin) groupID(fullPath string) (id int, err error) {
+	group, _, err := gls.Client.Groups.GetGroup(fullPath)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get '%s' group information. %s", fullPath, err)
+	}
+	return group.ID, nil
+}
+
+//ensureSharedGroups share the project with Groups. Shares are not managed if Groups is not set.
+// RemovedGroups are unshared. Other shares are left as is.
+// gitlab can't update a share access level, so the group is unshared and shared again.
+func (r *ProjectStruct) ensureSharedGroups(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	if r.Groups == nil {
+		return nil
+	}
+
+	current := make(map[int]gitlab.AccessLevelValue)
+	if project != nil {
+		for _, shared := range project.SharedWithGroups {
+			current[shared.GroupID] = gitlab.AccessLevelValue(shared.GroupAccessLevel)
+		}
+	}
+
+	for name, levelName := range r.Groups {
+		level := accessLevels[levelName]
+
+		groupID, err := gls.groupID(name)
+		if err != nil {
+			ret.Errorf("Unable to share '%s' with '%s'. %s", r.Name, name, err)
+			return err
+		}
+
+		currentLevel, shared := current[groupID]
+		if shared && currentLevel == level {
+			continue
+		}
+
+		if shared {
+			if gls.plannedChanges("shared group", r.Name+"/"+name, "update", []attributeChange{changed("access level", accessLevelName(currentLevel), levelName)}) {
+				continue
+			}
+		} else if gls.planned("shared group", r.Name+"/"+name, "create", fmt.Sprintf("access level '%s'", levelName)) {
+			continue
+		}
+
+		if shared {
+			if _, err := gls.Client.Projects.DeleteSharedProjectFromGroup(project.ID, groupID); err != nil {
+				ret.Errorf("Unable to update '%s' share with '%s'. %s", r.Name, name, err)
+				return err
+			}
+		}
+		options := &gitlab.ShareWithGroupOptions{GroupID: &groupID, GroupAccess: gitlab.AccessLevel(level)}
+		if _, err := gls.Client.Projects.ShareProjectWithGroup(project.ID, options); err != nil {
+			ret.Errorf("Unable to share '%s' with '%s'. %s", r.Name, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': shared with '%s' as '%s'", r.Name, name, levelName))
+	}
+
+	for _, name := range r.RemovedGroups {
+		groupID, err := gls.groupID(name)
+		if err != nil {
+			log.Printf("Repo '%s': '%s' not unshared. %s", r.Name, name, err)
+			continue
+		}
+		if _, shared := current[groupID]; !shared {
+			continue
+		}
+		if gls.planned("shared group", r.Name+"/"+name, "delete", "") {
+			continue
+		}
+		if _, err := gls.Client.Projects.DeleteSharedProjectFromGroup(project.ID, groupID); err != nil {
+			ret.Errorf("Unable to unshare '%s' with '%s'. %s", r.Name, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': unshared with '%s'", r.Name, name))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMembers(t *testing.T) {
+	tests := []struct {
+		list    string
+		members map[string]string
+		removed []string
+		fails   bool
+	}{
+		{"", map[string]string{}, nil, false},
+		{"alice", map[string]string{"alice": "developer"}, nil, false},
+		{" alice:Maintainer , +bob:guest,", map[string]string{"alice": "maintainer", "bob": "guest"}, nil, false},
+		{"alice,-bob", map[string]string{"alice": "developer"}, []string{"bob"}, false},
+		{"alice,-alice", map[string]string{}, []string{"alice"}, false},
+		{"-bob,bob:reporter", map[string]string{"bob": "reporter"}, nil, false},
+		{"alice:owner", nil, nil, true},
+		{"alice:admin", nil, nil, true},
+		{":developer", nil, nil, true},
+	}
+
+	for _, test := range tests {
+		members, removed, err := parseMembers(test.list)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseMembers(%q): error expected, got %v", test.list, members)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseMembers(%q): unexpected error %s", test.list, err)
+			continue
+		}
+		if !reflect.DeepEqual(members, test.members) {
+			t.Errorf("parseMembers(%q): members %v, expected %v", test.list, members, test.members)
+		}
+		if !reflect.DeepEqual(removed, test.removed) {
+			t.Errorf("parseMembers(%q): removed %v, expected %v", test.list, removed, test.removed)
+		}
+	}
+}
+
+func TestRemovedMembers(t *testing.T) {
+	declared := map[string]string{"alice": "developer"}
+	previous := map[string]string{"alice": "guest", "bob": "developer"}
+
+	got := removedMembers(declared, []string{"carol"}, previous, []string{"dave", "alice"})
+	if expected := []string{"bob", "carol", "dave"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("removedMembers: %v, expected %v", got, expected)
+	}
+}
+
+func TestKeepRemovedMembers(t *testing.T) {
+	tests := []struct {
+		name          string
+		project       ProjectStruct
+		previous      ProjectStruct
+		users         map[string]string
+		removedUsers  []string
+		groups        map[string]string
+		removedGroups []string
+	}{
+		{"not managed", ProjectStruct{}, ProjectStruct{}, nil, nil, nil, nil},
+		{"removed from the list",
+			ProjectStruct{Users: map[string]string{"alice": "developer"}, Groups: map[string]string{"acme/dev": "developer"}},
+			ProjectStruct{Users: map[string]string{"alice": "guest", "bob": "developer"}, Groups: map[string]string{"acme/dev": "developer", "acme/qa": "reporter"}},
+			map[string]string{"alice": "developer"}, []string{"bob"}, map[string]string{"acme/dev": "developer"}, []string{"acme/qa"}},
+		{"list emptied",
+			ProjectStruct{},
+			ProjectStruct{Users: map[string]string{"alice": "guest", "bob": "developer"}, Groups: map[string]string{"acme/qa": "reporter"}},
+			map[string]string{}, []string{"alice", "bob"}, map[string]string{}, []string{"acme/qa"}},
+		{"list emptied after the last member removed",
+			ProjectStruct{},
+			ProjectStruct{Users: nil, RemovedUsers: []string{"bob"}, RemovedGroups: []string{"acme/qa"}},
+			map[string]string{}, []string{"bob"}, map[string]string{}, []string{"acme/qa"}},
+	}
+
+	for _, test := range tests {
+		project := test.project
+		project.keepRemovedMembers(test.previous)
+		if !reflect.DeepEqual(project.Users, test.users) || !reflect.DeepEqual(project.RemovedUsers, test.removedUsers) {
+			t.Errorf("keepRemovedMembers(%s): users %v, removed %v, expected %v, removed %v", test.name, project.Users, project.RemovedUsers, test.users, test.removedUsers)
+		}
+		if !reflect.DeepEqual(project.Groups, test.groups) || !reflect.DeepEqual(project.RemovedGroups, test.removedGroups) {
+			t.Errorf("keepRemovedMembers(%s): groups %v, removed %v, expected %v, removed %v", test.name, project.Groups, project.RemovedGroups, test.groups, test.removedGroups)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//mergeMethods gitlab merge methods supported.
+var mergeMethods = []string{"merge", "rebase_merge", "ff"}
+
+//squashOptions gitlab squash options supported.
+var squashOptions = []string{"never", "always", "default_on", "default_off"}
+
+//MergeRequestsStruct project merge requests settings. Unset settings are not managed.
+type MergeRequestsStruct struct {
+	Method                    string                        `yaml:",omitempty"` // merge, rebase_merge or ff
+	Squash                    string                        `yaml:",omitempty"` // never, always, default_on or default_off
+	RemoveSourceBranch        *bool                         `yaml:"remove-source-branch,omitempty"`
+	PipelinesMustSucceed      *bool                         `yaml:"pipelines-must-succeed,omitempty"`
+	DiscussionsMustBeResolved *bool                         `yaml:"discussions-must-be-resolved,omitempty"`
+	Approvals                 *int                          `yaml:",omitempty"` // approvals required before merge
+	ApprovalRules             map[string]ApprovalRuleStruct `yaml:"approval-rules,omitempty"`
+}
+
+//ApprovalRuleStruct merge requests approval rule. Identified in gitlab by its name.
+type ApprovalRuleStruct struct {
+	Approvals int
+	Users     []string `yaml:",omitempty"`
+	Groups    []string `yaml:",omitempty"` // full path
+}
+
+//gitlabMergeSettings project merge requests settings not implemented by go-gitlab
+type gitlabMergeSettings struct {
+	SquashOption                 string `json:"squash_option,omitempty"`
+	RemoveSourceBranchAfterMerge *bool  `json:"remove_source_branch_after_merge,omitempty"`
+}
+
+//gitlabApprovalRule project approval rule read from gitlab
+type gitlabApprovalRule struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	RuleType          string `json:"rule_type"`
+	ApprovalsRequired int    `json:"approvals_required"`
+	Users             []struct {
+		Username string `json:"username"`
+	} `json:"users"`
+	Groups []struct {
+		FullPath string `json:"full_path"`
+	} `json:"groups"`
+}
+
+//approvalRuleOptions project approval rule attributes sent to gitlab
+type approvalRuleOptions struct {
+	Name              *string `json:"name"`
+	ApprovalsRequired *int    `json:"approvals_required"`
+	UserIDs           []int   `json:"user_ids"`
+	GroupIDs          []int   `json:"group_ids"`
+}
+
+//boolFlag return nil if the flag is not set, or the flag value.
+func boolFlag(value string) *bool {
+	if value == "" {
+		return nil
+	}
+	return gitlab.Bool(value == "true")
+}
+
+//parseApprovalRules return approval rules from a list separated by comma: name:approvals[:member+member...]
+// Group members are prefixed by '@'.
+func parseApprovalRules(list string) (rules map[string]ApprovalRuleStruct, err error) {
+	for _, rule := range splitList(list) {
+		fields := strings.SplitN(rule, ":", 3)
+		if len(fields) < 2 || fields[0] == "" {
+			return nil, fmt.Errorf("Invalid approval rule '%s'. Format is name:approvals[:member+member...].", rule)
+		}
+		ruleData := ApprovalRuleStruct{}
+		if ruleData.Approvals, err = strconv.Atoi(fields[1]); err != nil || ruleData.Approvals < 0 {
+			return nil, fmt.Errorf("Invalid approval rule '%s'. '%s' is not a valid number of approvals.", rule, fields[1])
+		}
+		if len(fields) == 3 {
+			for _, member := range strings.Split(fields[2], "+") {
+				switch {
+				case member == "" || member == "@":
+					return nil, fmt.Errorf("Invalid approval rule '%s'. Member name is empty.", rule)
+				case strings.HasPrefix(member, "@"):
+					ruleData.Groups = append(ruleData.Groups, member[1:])
+				default:
+					ruleData.Users = append(ruleData.Users, member)
+				}
+			}
+		}
+		if rules == nil {
+			rules = make(map[string]ApprovalRuleStruct)
+		}
+		rules[fields[0]] = ruleData
+	}
+	return rules, nil
+}
+
+//isValidMergeRequests verify the repo merge requests settings.
+func (r *RepoInstanceStruct) isValidMergeRequests() error {
+	if r.MergeMethod != "" && !inList(r.MergeMethod, mergeMethods) {
+		return fmt.Errorf("Unknown merge method '%s'. Valid methods are %s.", r.MergeMethod, strings.Join(mergeMethods, ", "))
+	}
+	if r.Squash != "" && !inList(r.Squash, squashOptions) {
+		return fmt.Errorf("Unknown squash option '%s'. Valid options are %s.", r.Squash, strings.Join(squashOptions, ", "))
+	}
+	if r.Approvals != "" {
+		if n, err := strconv.Atoi(r.Approvals); err != nil || n < 0 {
+			return fmt.Errorf("'%s' is not a valid number of approvals.", r.Approvals)
+		}
+	}
+	_, err := parseApprovalRules(r.ApprovalRules)
+	return err
+}
+
+//set merge requests settings from the forjj repo validated by isValid.
+func (m *MergeRequestsStruct) set(project *RepoInstanceStruct) *MergeRequestsStruct {
+	if m == nil {
+		m = new(MergeRequestsStruct)
+	}
+	m.Method = project.MergeMethod
+	m.Squash = project.Squash
+	m.RemoveSourceBranch = boolFlag(project.RemoveSourceBranch)
+	m.PipelinesMustSucceed = boolFlag(project.PipelinesMustSucceed)
+	m.DiscussionsMustBeResolved = boolFlag(project.DiscussionsMustBeResolved)
+	m.Approvals = nil
+	if n, err := strconv.Atoi(project.Approvals); err == nil {
+		m.Approvals = gitlab.Int(n)
+	}
+	m.ApprovalRules, _ = parseApprovalRules(project.ApprovalRules)
+	return m
+}
+
+//complete set unset settings from defaults.
+func (m *MergeRequestsStruct) complete(defaults *MergeRequestsStruct) {
+	if m.Method == "" {
+		m.Method = defaults.Method
+	}
+	if m.Squash == "" {
+		m.Squash = defaults.Squash
+	}
+	if m.RemoveSourceBranch == nil {
+		m.RemoveSourceBranch = defaults.RemoveSourceBranch
+	}
+	if m.PipelinesMustSucceed == nil {
+		m.PipelinesMustSucceed = defaults.PipelinesMustSucceed
+	}
+	if m.DiscussionsMustBeResolved == nil {
+		m.DiscussionsMustBeResolved = defaults.DiscussionsMustBeResolved
+	}
+	if m.Approvals == nil {
+		m.Approvals = defaults.Approvals
+	}
+	if m.ApprovalRules == nil {
+		m.ApprovalRules = defaults.ApprovalRules
+	}
+}
+
+//createOptions set merge requests settings supported by the project creation.
+func (m *MergeRequestsStruct) createOptions(options *gitlab.CreateProjectOptions) {
+	if m.Method != "" {
+		options.MergeMethod = gitlab.MergeMethod(gitlab.MergeMethodValue(m.Method))
+	}
+	options.OnlyAllowMergeIfPipelineSucceeds = m.PipelinesMustSucceed
+	options.OnlyAllowMergeIfAllDiscussionsAreResolved = m.DiscussionsMustBeResolved
+	if m.Approvals != nil {
+		options.ApprovalsBeforeMerge = m.Approvals
+	}
+}
+
+//changes compare the gitlab project with merge requests settings and set changed attributes in options.
+func (m *MergeRequestsStruct) changes(project *gitlab.Project, options *gitlab.EditProjectOptions) (changes []attributeChange) {
+	if m.Method != "" && project.MergeMethod != m.Method {
+		options.MergeMethod = gitlab.MergeMethod(gitlab.MergeMethodValue(m.Method))
+		changes = append(changes, changed("merge method", project.MergeMethod, m.Method))
+	}
+	if m.PipelinesMustSucceed != nil && project.OnlyAllowMergeIfPipelineSucceeds != *m.PipelinesMustSucceed {
+		options.OnlyAllowMergeIfPipelineSucceeds = m.PipelinesMustSucceed
+		changes = append(changes, changed("pipelines must succeed", project.OnlyAllowMergeIfPipelineSucceeds, *m.PipelinesMustSucceed))
+	}
+	if m.DiscussionsMustBeResolved != nil && project.OnlyAllowMergeIfAllDiscussionsAreResolved != *m.DiscussionsMustBeResolved {
+		options.OnlyAllowMergeIfAllDiscussionsAreResolved = m.DiscussionsMustBeResolved
+		changes = append(changes, changed("discussions must be resolved", project.OnlyAllowMergeIfAllDiscussionsAreResolved, *m.DiscussionsMustBeResolved))
+	}
+	if m.Approvals != nil && project.ApprovalsBeforeMerge != *m.Approvals {
+		options.ApprovalsBeforeMerge = m.Approvals
+		changes = append(changes, changed("approvals before merge", project.ApprovalsBeforeMerge, *m.Approvals))
+	}
+	return
+}
+
+//ensureMergeSettings maintain project merge requests settings not implemented by go-gitlab.
+// project is nil if not created yet (plan mode).
+func (r *ProjectStruct) ensureMergeSettings(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	m := &r.MergeRequests
+	if project == nil || (m.Squash == "" && m.RemoveSourceBranch == nil) {
+		return nil
+	}
+
+	projectPath := fmt.Sprintf("projects/%d", project.ID)
+	live := new(gitlabMergeSettings)
+	if err := gls.apiRequest("GET", projectPath, nil, live); err != nil {
+		ret.Errorf("Unable to get '%s' merge requests settings. %s", r.Name, err)
+		return err
+	}
+
+	options := new(gitlabMergeSettings)
+	var changes []attributeChange
+	if m.Squash != "" && live.SquashOption != m.Squash {
+		options.SquashOption = m.Squash
+		changes = append(changes, changed("squash option", live.SquashOption, m.Squash))
+	}
+	if m.RemoveSourceBranch != nil {
+		current := live.RemoveSourceBranchAfterMerge != nil && *live.RemoveSourceBranchAfterMerge
+		if current != *m.RemoveSourceBranch {
+			options.RemoveSourceBranchAfterMerge = m.RemoveSourceBranch
+			changes = append(changes, changed("remove source branch", current, *m.RemoveSourceBranch))
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if gls.plannedChanges("project", r.Name, "update", changes) {
+		return nil
+	}
+	if err := gls.apiRequest("PUT", projectPath, options, nil); err != nil {
+		ret.Errorf("Unable to update '%s' merge requests settings. %s", r.Name, err)
+		return err
+	}
+	for _, change := range changes {
+		log.Printf(ret.StatusAdd("Repo '%s': %s", r.Name, change))
+	}
+	return nil
+}
+
+//sameNames return true if both lists contain the same names.
+func sameNames(live, declared []string) bool {
+	if len(live) != len(declared) {
+		return false
+	}
+	sort.Strings(live)
+	declared = append([]string(nil), declared...)
+	sort.Strings(declared)
+	for i := range live {
+		if live[i] != declared[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//changes return approval rule attributes changed in gitlab.
+func (a *ApprovalRuleStruct) changes(rule *gitlabApprovalRule) (changes []attributeChange) {
+	if rule.ApprovalsRequired != a.Approvals {
+		changes = append(changes, changed("approvals", rule.ApprovalsRequired, a.Approvals))
+	}
+	var users, groups []string
+	for _, user := range rule.Users {
+		users = append(users, user.Username)
+	}
+	for _, group := range rule.Groups {
+		groups = append(groups, group.FullPath)
+	}
+	if !sameNames(users, a.Users) {
+		changes = append(changes, changed("users", strings.Join(users, ", "), strings.Join(a.Users, ", ")))
+	}
+	if !sameNames(groups, a.Groups) {
+		changes = append(changes, changed("groups", strings.Join(groups, ", "), strings.Join(a.Groups, ", ")))
+	}
+	return
+}
+
+//options return gitlab approval rule options.
+func (a *ApprovalRuleStruct) options(gls *GitlabPlugin, name string) (*approvalRuleOptions, error) {
+	options := &approvalRuleOptions{
+		Name:              &name,
+		ApprovalsRequired: gitlab.Int(a.Approvals),
+		UserIDs:           []int{},
+		GroupIDs:          []int{},
+	}
+	for _, user := range a.Users {
+		id, err := gls.userID(user)
+		if err != nil {
+			return nil, err
+		}
+		options.UserIDs = append(options.UserIDs, id)
+	}
+	for _, group := range a.Groups {
+		id, err := gls.groupID(group)
+		if err != nil {
+			return nil, err
+		}
+		options.GroupIDs = append(options.GroupIDs, id)
+	}
+	return options, nil
+}
+
+//ensureApprovalRules create, update or delete the project approval rules. Only regular rules are managed.
+// Rules are not managed if none are declared. project is nil if not created yet (plan mode).
+func (r *ProjectStruct) ensureApprovalRules(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	rules := r.MergeRequests.ApprovalRules
+	if rules == nil {
+		return nil
+	}
+
+	current := make(map[string]*gitlabApprovalRule)
+	rulesPath := ""
+	if project != nil {
+		rulesPath = fmt.Sprintf("projects/%d/approval_rules", project.ID)
+		var list []*gitlabApprovalRule
+		if err := gls.apiRequest("GET", rulesPath, nil, &list); err != nil {
+			if status := errorStatus(err); status == 403 || status == 404 {
+				log.Printf(ret.StatusAdd("Warning!!! Repo '%s': approval rules are not available in this gitlab edition. Ignored.", r.Name))
+				return nil
+			}
+			ret.Errorf("Unable to get '%s' approval rules. %s", r.Name, err)
+			return err
+		}
+		for _, rule := range list {
+			if rule.RuleType == "" || rule.RuleType == "regular" {
+				current[rule.Name] = rule
+			}
+		}
+	}
+
+	for name, rule := range rules {
+		live, found := current[name]
+		if found {
+			changes := rule.changes(live)
+			if len(changes) == 0 || gls.plannedChanges("approval rule", r.Name+"/"+name, "update", changes) {
+				continue
+			}
+		} else if gls.planned("approval rule", r.Name+"/"+name, "create", fmt.Sprintf("%d approval(s)", rule.Approvals)) {
+			continue
+		}
+
+		options, err := rule.options(gls, name)
+		if err != nil {
+			ret.Errorf("Unable to set '%s' approval rule '%s'. %s", r.Name, name, err)
+			return err
+		}
+		if found {
+			if err := gls.apiRequest("PUT", fmt.Sprintf("%s/%d", rulesPath, live.ID), options, nil); err != nil {
+				ret.Errorf("Unable to update '%s' approval rule '%s'. %s", r.Name, name, err)
+				return err
+			}
+			log.Printf(ret.StatusAdd("Repo '%s': approval rule '%s' updated", r.Name, name))
+			continue
+		}
+		if err := gls.apiRequest("POST", rulesPath, options, nil); err != nil {
+			ret.Errorf("Unable to create '%s' approval rule '%s'. %s", r.Name, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': approval rule '%s' created", r.Name, name))
+	}
+
+	for name, live := range current {
+		if _, declared := rules[name]; declared {
+			continue
+		}
+		if gls.planned("approval rule", r.Name+"/"+name, "delete", "") {
+			continue
+		}
+		if err := gls.apiRequest("DELETE", fmt.Sprintf("%s/%d", rulesPath, live.ID), nil, nil); err != nil {
+			ret.Errorf("Unable to delete '%s' approval rule '%s'. %s", r.Name, name, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': approval rule '%s' deleted", r.Name, name))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseApprovalRules(t *testing.T) {
+	tests := []struct {
+		list  string
+		rules map[string]ApprovalRuleStruct
+		fails bool
+	}{
+		{"", nil, false},
+		{"all:2", map[string]ApprovalRuleStruct{"all": {Approvals: 2}}, false},
+		{"security:1:@acme/security+john, qa:0:bob", map[string]ApprovalRuleStruct{
+			"security": {Approvals: 1, Users: []string{"john"}, Groups: []string{"acme/security"}},
+			"qa":       {Approvals: 0, Users: []string{"bob"}},
+		}, false},
+		{"all", nil, true},
+		{":1", nil, true},
+		{"all:two", nil, true},
+		{"all:-1", nil, true},
+		{"all:1:john+", nil, true},
+		{"all:1:@", nil, true},
+	}
+
+	for _, test := range tests {
+		rules, err := parseApprovalRules(test.list)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseApprovalRules(%q): error expected, got %v", test.list, rules)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseApprovalRules(%q): unexpected error %s", test.list, err)
+			continue
+		}
+		if !reflect.DeepEqual(rules, test.rules) {
+			t.Errorf("parseApprovalRules(%q): %v, expected %v", test.list, rules, test.rules)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+//deployVersion is the forjj-gitlab.yaml schema version written by this plugin.
+// Increase it and add a migration in deployMigrations each time GitlabDeployStruct changes in an incompatible way.
+const deployVersion = 2
+
+//yamlDoc raw yaml document
+type yamlDoc map[interface{}]interface{}
+
+//deployMigrations upgrade a raw deploy document from version <index> to version <index+1>
+var deployMigrations = []func(yamlDoc) error{
+	migrateDeployV0,
+	migrateDeployV1,
+}
+
+//migrateDeploy upgrade the raw deploy document from version to deployVersion.
+func migrateDeploy(doc yamlDoc, version int) error {
+	if version > deployVersion {
+		return fmt.Errorf("The gitlab deploy data schema version is %d. This forjj-gitlab supports version %d at most. Upgrade forjj-gitlab.", version, deployVersion)
+	}
+	if version < 0 {
+		return fmt.Errorf("Invalid gitlab deploy data schema version %d.", version)
+	}
+
+	for ; version < deployVersion; version++ {
+		if err := deployMigrations[version](doc); err != nil {
+			return fmt.Errorf("Unable to migrate gitlab deploy data from version %d to %d. %s", version, version+1, err)
+		}
+		log.Printf("Gitlab deploy data migrated from version %d to %d.", version, version+1)
+	}
+	doc["version"] = deployVersion
+	return nil
+}
+
+//migrateDeployV0 add the gitlab api url and the projects issue tracker, not stored by unversioned deploy files.
+// The issue tracker was enabled by default.
+func migrateDeployV0(doc yamlDoc) error {
+	setProjectsIssueTracker(doc, true)
+
+	urls, ok := doc["urls"].(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+	if _, found := urls["gitlab-api-url"]; found {
+		return nil
+	}
+	if baseUrl, ok := urls["gitlab-base-url"].(string); ok && baseUrl != "" {
+		urls["gitlab-api-url"] = strings.TrimSuffix(baseUrl, "/") + "/api/v4/"
+	}
+	return nil
+}
+
+//migrateDeployV1 write the projects issue tracker always. Version 1 deploy files omitted a disabled issue tracker.
+func migrateDeployV1(doc yamlDoc) error {
+	setProjectsIssueTracker(doc, false)
+	return nil
+}
+
+//setProjectsIssueTracker set the issue tracker of projects which have none.
+func setProjectsIssueTracker(doc yamlDoc, enabled bool) {
+	projects, ok := doc["projects"].(map[interface{}]interface{})
+	if !ok {
+		return
+	}
+	for _, project := range projects {
+		if projectData, ok := project.(map[interface{}]interface{}); ok {
+			if _, found := projectData["issue_tracker"]; !found {
+				projectData["issue_tracker"] = enabled
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrateDeploy(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      yamlDoc
+		version  int
+		expected yamlDoc
+		fails    bool
+	}{
+		{"v0", yamlDoc{
+			"urls":     map[interface{}]interface{}{"gitlab-base-url": "https://gitlab.com/"},
+			"projects": map[interface{}]interface{}{"app": map[interface{}]interface{}{"name": "app"}},
+		}, 0, yamlDoc{
+			"version":  deployVersion,
+			"urls":     map[interface{}]interface{}{"gitlab-base-url": "https://gitlab.com/", "gitlab-api-url": "https://gitlab.com/api/v4/"},
+			"projects": map[interface{}]interface{}{"app": map[interface{}]interface{}{"name": "app", "issue_tracker": true}},
+		}, false},
+		{"v1", yamlDoc{
+			"urls": map[interface{}]interface{}{"gitlab-base-url": "https://gitlab.com/", "gitlab-api-url": "https://api.example.com/"},
+			"projects": map[interface{}]interface{}{
+				"app": map[interface{}]interface{}{"name": "app"},
+				"doc": map[interface{}]interface{}{"name": "doc", "issue_tracker": true},
+			},
+		}, 1, yamlDoc{
+			"version": deployVersion,
+			"urls":    map[interface{}]interface{}{"gitlab-base-url": "https://gitlab.com/", "gitlab-api-url": "https://api.example.com/"},
+			"projects": map[interface{}]interface{}{
+				"app": map[interface{}]interface{}{"name": "app", "issue_tracker": false},
+				"doc": map[interface{}]interface{}{"name": "doc", "issue_tracker": true},
+			},
+		}, false},
+		{"current", yamlDoc{
+			"projects": map[interface{}]interface{}{"app": map[interface{}]interface{}{"name": "app"}},
+		}, deployVersion, yamlDoc{
+			"version":  deployVersion,
+			"projects": map[interface{}]interface{}{"app": map[interface{}]interface{}{"name": "app"}},
+		}, false},
+		{"too new", yamlDoc{}, deployVersion + 1, nil, true},
+		{"negative", yamlDoc{}, -1, nil, true},
+	}
+
+	for _, test := range tests {
+		err := migrateDeploy(test.doc, test.version)
+		if test.fails {
+			if err == nil {
+				t.Errorf("migrateDeploy(%s): error expected", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("migrateDeploy(%s): unexpected error %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.doc, test.expected) {
+			t.Errorf("migrateDeploy(%s): %v, expected %v", test.name, test.doc, test.expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//transferOptions project transfer attributes sent to gitlab
+type transferOptions struct {
+	Namespace *int `json:"namespace"`
+}
+
+//findMoved return the gitlab project of a project renamed or moved to another group, from its ID or its former name.
+// It returns nil if the project is not found.
+func (r *ProjectStruct) findMoved(gls *GitlabPlugin) (project *gitlab.Project, err error) {
+	var pid interface{}
+	switch {
+	case r.ID != 0:
+		pid = r.ID
+	case r.FormerName != "":
+		pid = gls.projectPath(r.FormerName)
+	default:
+		return nil, nil
+	}
+
+	project, resp, err := gls.Client.Projects.GetProject(pid)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Unable to get '%s' project information. %s", r.Name, err)
+	}
+	return project, nil
+}
+
+//move transfer the project to the forge group and rename it if needed. The moved project is returned.
+// In plan mode, the project is returned unchanged.
+func (r *ProjectStruct) move(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) (*gitlab.Project, error) {
+	from := project.PathWithNamespace
+
+	if project.Namespace == nil || project.Namespace.ID != gls.gitlabDeploy.GroupId {
+		if !gls.planned("project", r.Name, "transfer", fmt.Sprintf("from '%s' to '%s'", from, gls.gitlabDeploy.Group)) {
+			moved := new(gitlab.Project)
+			options := &transferOptions{Namespace: &gls.gitlabDeploy.GroupId}
+			if err := gls.apiRequest("PUT", fmt.Sprintf("projects/%d/transfer", project.ID), options, moved); err != nil {
+				ret.Errorf("Unable to transfer '%s' to '%s'. %s", from, gls.gitlabDeploy.Group, err)
+				return nil, err
+			}
+			project = moved
+			log.Printf(ret.StatusAdd("Repo '%s': transferred from '%s' to '%s'", r.Name, from, gls.gitlabDeploy.Group))
+		}
+	}
+
+	//only the path is renamed. The display name is kept.
+	if project.Path != r.Name {
+		if !gls.planned("project", r.Name, "rename", fmt.Sprintf("from '%s'", project.Path)) {
+			options := &gitlab.EditProjectOptions{
+				Path:                 &r.Name,
+				ApprovalsBeforeMerge: &project.ApprovalsBeforeMerge, //never sent empty (restriction SQL: not null)
+			}
+			renamed, _, err := gls.Client.Projects.EditProject(project.ID, options)
+			if err != nil {
+				ret.Errorf("Unable to rename '%s' to '%s'. %s", from, r.Name, err)
+				return nil, err
+			}
+			log.Printf(ret.StatusAdd("Repo '%s': renamed from '%s'", r.Name, project.Path))
+			project = renamed
+		}
+	}
+	return project, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/forj-oss/goforjj"
+)
+
+//attributeChange an attribute value different in gitlab and in the deploy data.
+type attributeChange struct {
+	Attribute string `json:"attribute"`
+	Gitlab    string `json:"gitlab"` // live value
+	Deploy    string `json:"deploy"` // deploy data value
+}
+
+//planChange describe a change maintain would apply in gitlab (plan mode)
+type planChange struct {
+	Kind             string `json:"kind"` // group, project, member, hook or setting
+	Name             string `json:"name"`
+	Action           string `json:"action"` // create, update or delete
+	Detail           string `json:"detail,omitempty"`
+	*attributeChange        // attribute updated
+}
+
+//planResponse maintain answer with the planned changes as data. Plan is nil if not in plan mode.
+type planResponse struct {
+	*goforjj.PluginData
+	Plan []planChange `json:"plan"`
+}
+
+//changed return the attribute change. Values are formatted with %v.
+func changed(attribute string, gitlab, deploy interface{}) attributeChange {
+	return attributeChange{Attribute: attribute, Gitlab: fmt.Sprint(gitlab), Deploy: fmt.Sprint(deploy)}
+}
+
+//String return the human readable attribute change.
+func (c attributeChange) String() string {
+	return fmt.Sprintf("%s changed from '%s' to '%s'", c.Attribute, c.Gitlab, c.Deploy)
+}
+
+//String return the human readable change.
+func (c planChange) String() string {
+	switch {
+	case c.attributeChange != nil:
+		return fmt.Sprintf("%s %s '%s': %s", c.Action, c.Kind, c.Name, c.attributeChange)
+	case c.Detail == "":
+		return fmt.Sprintf("%s %s '%s'", c.Action, c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s %s '%s': %s", c.Action, c.Kind, c.Name, c.Detail)
+}
+
+//planned register the change and return true in plan mode. Then the caller must not call gitlab write API.
+func (gls *GitlabPlugin) planned(kind, name, action, detail string) bool {
+	if !gls.plan {
+		return false
+	}
+	gls.planChanges = append(gls.planChanges, planChange{
+		Kind:   kind,
+		Name:   name,
+		Action: action,
+		Detail: detail,
+	})
+	return true
+}
+
+//plannedChanges register one change per changed attribute and return true in plan mode.
+func (gls *GitlabPlugin) plannedChanges(kind, name, action string, changes []attributeChange) bool {
+	if !gls.plan {
+		return false
+	}
+	for i := range changes {
+		gls.planChanges = append(gls.planChanges, planChange{
+			Kind:            kind,
+			Name:            name,
+			Action:          action,
+			attributeChange: &changes[i],
+		})
+	}
+	return true
+}
+
+//planReport add the list of planned changes to the plugin answer, as data and as status.
+func (gls *GitlabPlugin) planReport(ret *goforjj.PluginData, plan *[]planChange) {
+	*plan = append([]planChange{}, gls.planChanges...)
+	if len(gls.planChanges) == 0 {
+		log.Printf(ret.StatusAdd("Plan: No changes. Gitlab is up to date."))
+		return
+	}
+
+	log.Printf(ret.StatusAdd("Plan: %d change(s) to apply.", len(gls.planChanges)))
+	for _, change := range gls.planChanges {
+		log.Printf(ret.StatusAdd("Plan: %s", change))
+	}
+}
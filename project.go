@@ -1,22 +1,42 @@
 package main
 
 import(
+	"strings"
+
 	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
 )
 
+const defaultBranch = "master"
+
 //ProjectStruct (TODO)
 type ProjectStruct struct {
 	Name 			string
+	ID 			int 				`yaml:"id,omitempty"` // gitlab project ID
+	FormerName 		string 				`yaml:"former-name,omitempty"`
 	Flow 			string 				`yaml:",omitempty"`
+	DefaultBranch 		string 				`yaml:"default-branch,omitempty"`
 	Description		string 				`yaml:",omitempty"`
 	Disabled 		bool				`yaml:",omitempty"`
-	IssueTracker 		bool 				`yaml:"issue_tracker,omitempty"`
+	Removed 		bool				`yaml:",omitempty"` // removed from the Forjfile
+	Imported 		bool				`yaml:",omitempty"` // imported from gitlab, not declared in the Forjfile
+	IssueTracker 		bool 				`yaml:"issue_tracker"` // always written (deploy version 2)
+	Visibility 		string 				`yaml:",omitempty"`
 	Users 			map[string]string 		`yaml:",omitempty"`
-	//Groups
+	RemovedUsers 		[]string 			`yaml:"removed-users,omitempty"` // members to revoke
+	Groups 			map[string]string 		`yaml:",omitempty"`
+	RemovedGroups 		[]string 			`yaml:"removed-groups,omitempty"` // groups to unshare
+	WebHooks 		map[string]WebHookStruct 	`yaml:",omitempty"`
+	WebhooksManagement 	string 				`yaml:"webhooks-management,omitempty"`
+	ProtectedBranches 	map[string]ProtectionStruct 	`yaml:"protected-branches,omitempty"` // by branch name pattern
+	ProtectedTags 		map[string]ProtectionStruct 	`yaml:"protected-tags,omitempty"` // by tag name pattern
+	ProtectionsManagement 	string 				`yaml:"protections-management,omitempty"`
+	MergeRequests 		MergeRequestsStruct 		`yaml:"merge-requests,omitempty"`
+	Features 		FeaturesStruct 			`yaml:",omitempty"`
 
-	exist 			bool 				`yaml:",omitempty"`
-	remotes 		map[string]goforjj.PluginRepoRemoteUrl
-	branchConnect 		map[string]string 	
+	Exist 			bool 				`yaml:",omitempty"` // found in gitlab
+	Remotes 		map[string]goforjj.PluginRepoRemoteUrl 	`yaml:",omitempty"`
+	BranchConnect 		map[string]string 		`yaml:"branch-connect,omitempty"` // local branch: remote/branch
 	//...
 
 	//maintain
@@ -38,6 +58,38 @@ func (r *RepoInstanceStruct) isValid(repoName string, ret *goforjj.PluginData) (
 		ret.Errorf("Invalid project '%s'. Name must be equal to '%s'. But the project name is set to '%s'.", repoName, repoName, r.Name)
 		return
 	}
+	if _, _, err := parseMembers(r.Users); err != nil {
+		ret.Errorf("Invalid project '%s' users. %s", repoName, err)
+		return
+	}
+	if _, _, err := parseMembers(r.Groups); err != nil {
+		ret.Errorf("Invalid project '%s' groups. %s", repoName, err)
+		return
+	}
+	if m := r.WebhooksManagement; m != "" && m != "sync" && m != "manage" {
+		ret.Errorf("Invalid project '%s'. webhooks-management must be 'sync' or 'manage'. Got '%s'.", repoName, m)
+		return
+	}
+	if m := r.ProtectionsManagement; m != "" && m != "sync" && m != "manage" {
+		ret.Errorf("Invalid project '%s'. protections-management must be 'sync' or 'manage'. Got '%s'.", repoName, m)
+		return
+	}
+	if err := r.isValidMergeRequests(); err != nil {
+		ret.Errorf("Invalid project '%s' merge requests settings. %s", repoName, err)
+		return
+	}
+	if d := r.Disabled; d != "" && d != "true" && d != "false" {
+		ret.Errorf("Invalid project '%s'. disabled must be 'true' or 'false'. Got '%s'.", repoName, d)
+		return
+	}
+	if err := isValidVisibility(r.Visibility); err != nil {
+		ret.Errorf("Invalid project '%s'. %s", repoName, err)
+		return
+	}
+	if err := r.isValidFeatures(); err != nil {
+		ret.Errorf("Invalid project '%s' features. %s", repoName, err)
+		return
+	}
 	valid = true
 	return
 }
@@ -50,19 +102,24 @@ func (r *ProjectStruct) set(project *RepoInstanceStruct, remotes map[string]gofo
 	r.Name = project.Name
 	r.Description = project.Title
 
-	//issueTracker
+	r.IssueTracker = (project.Issue_tracker == "true")
 
 	r.Flow = project.Flow
+	r.FormerName = project.FormerName
+	r.Visibility = project.Visibility
+	r.Disabled = (project.Disabled == "true")
+	r.DefaultBranch = project.DefaultBranch
 	r.Infra = isInfra
 
-	//r.addUsers(project.Users)
-	//Groups
+	r.MergeRequests.set(project)
+	r.Features.set(project)
 
-	r.remotes = remotes
-	r.branchConnect = branchConnect
-	
-	//WebHooks
+	r.addUsers(project.Users)
+	r.addGroups(project.Groups)
 
+	r.Remotes = remotes
+	r.BranchConnect = branchConnect
+	
 	r.Role = project.Role
 	r.Owner = owner
 	r.IsDeployable = IsDeployable
@@ -70,7 +127,123 @@ func (r *ProjectStruct) set(project *RepoInstanceStruct, remotes map[string]gofo
 	return r
 }
 
-//addUsers (TODO)
+//addUsers set project members from the users list ([+-]user[:level],...) validated by isValid.
+// Users is nil if the list is not set: members are not managed.
 func (r *ProjectStruct) addUsers(users string) {
-	//
+	r.Users, r.RemovedUsers = nil, nil
+	if strings.TrimSpace(users) == "" {
+		return
+	}
+	r.Users, r.RemovedUsers, _ = parseMembers(users)
+}
+
+//keepRemovedMembers add previous members and shared groups not declared anymore to the ones to revoke.
+// If the users or groups list is emptied, previous members or shared groups are all revoked.
+func (r *ProjectStruct) keepRemovedMembers(previous ProjectStruct) {
+	if r.Users == nil && (previous.Users != nil || previous.RemovedUsers != nil) {
+		r.Users = make(map[string]string)
+	}
+	if r.Users != nil {
+		r.RemovedUsers = removedMembers(r.Users, r.RemovedUsers, previous.Users, previous.RemovedUsers)
+	}
+	if r.Groups == nil && (previous.Groups != nil || previous.RemovedGroups != nil) {
+		r.Groups = make(map[string]string)
+	}
+	if r.Groups != nil {
+		r.RemovedGroups = removedMembers(r.Groups, r.RemovedGroups, previous.Groups, previous.RemovedGroups)
+	}
+}
+
+//addGroups set groups to share the project with from the groups list ([+-]group[:level],...) validated by isValid.
+// Groups is nil if the list is not set: shares are not managed.
+func (r *ProjectStruct) addGroups(groups string) {
+	r.Groups, r.RemovedGroups = nil, nil
+	if strings.TrimSpace(groups) == "" {
+		return
+	}
+	r.Groups, r.RemovedGroups, _ = parseMembers(groups)
+}
+
+//changes compare the gitlab project with the project definition and return edit options with changed attributes.
+func (r *ProjectStruct) changes(project *gitlab.Project) (options *gitlab.EditProjectOptions, changes []attributeChange) {
+	options = &gitlab.EditProjectOptions{
+		ApprovalsBeforeMerge: &project.ApprovalsBeforeMerge, //never sent empty (restriction SQL: not null)
+	}
+
+	if project.Description != r.Description {
+		options.Description = &r.Description
+		changes = append(changes, changed("description", project.Description, r.Description))
+	}
+	if project.IssuesEnabled != r.IssueTracker {
+		options.IssuesEnabled = &r.IssueTracker
+		changes = append(changes, changed("issue tracker", project.IssuesEnabled, r.IssueTracker))
+	}
+	if r.Visibility != "" && project.Visibility != gitlab.VisibilityValue(r.Visibility) {
+		options.Visibility = gitlab.Visibility(gitlab.VisibilityValue(r.Visibility))
+		changes = append(changes, changed("visibility", project.Visibility, r.Visibility))
+	}
+	//An empty project has no default branch. gitlab will use the first branch pushed.
+	if r.DefaultBranch != "" && project.DefaultBranch != "" && project.DefaultBranch != r.DefaultBranch {
+		options.DefaultBranch = &r.DefaultBranch
+		changes = append(changes, changed("default branch", project.DefaultBranch, r.DefaultBranch))
+	}
+	changes = append(changes, r.MergeRequests.changes(project, options)...)
+	changes = append(changes, r.Features.changes(project, options)...)
+	//Flow: applied on project settings by applyFlow
+
+	return
+}
+
+//setRemotes set the origin remote and connect the gitlab project default branch to it.
+func (r *ProjectStruct) setRemotes(project *gitlab.Project) {
+	r.Exist = true
+	r.ID = project.ID
+	if r.Remotes == nil {
+		r.Remotes = make(map[string]goforjj.PluginRepoRemoteUrl)
+	}
+	r.Remotes["origin"] = goforjj.PluginRepoRemoteUrl{
+		Ssh: project.SSHURLToRepo,
+		Url: project.HTTPURLToRepo,
+	}
+
+	branch := r.defaultBranch()
+	if branch == "" {
+		branch = project.DefaultBranch
+	}
+	r.connectBranch(branch)
+}
+
+//connectBranch connect the default branch to the origin remote. 'master' is used if branch is empty.
+func (r *ProjectStruct) connectBranch(branch string) {
+	if branch == "" {
+		branch = defaultBranch
+	}
+	r.BranchConnect = map[string]string{branch: "origin/" + branch}
+}
+
+//keepRemotes restore the gitlab project ID, remotes and branch connections stored in deploy data for an existing project.
+// Only the ID is kept for a renamed project: its remotes are set again from gitlab.
+func (r *ProjectStruct) keepRemotes(stored ProjectStruct, renamed bool) {
+	r.ID = stored.ID
+	if renamed || !stored.Exist {
+		return
+	}
+	r.Exist = true
+	if len(stored.Remotes) > 0 {
+		r.Remotes = stored.Remotes
+	}
+	if len(stored.BranchConnect) > 0 && r.defaultBranch() == "" {
+		r.BranchConnect = stored.BranchConnect
+	}
+}
+
+//pluginRepo return the forjj repository data of the project.
+func (r *ProjectStruct) pluginRepo() goforjj.PluginRepo {
+	return goforjj.PluginRepo{
+		Name:          r.Name,
+		Exist:         r.Exist,
+		Remotes:       r.Remotes,
+		BranchConnect: r.BranchConnect,
+		Owner:         r.Owner,
+	}
 }
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//protectionLevels map forjj protection access level names to gitlab access levels
+var protectionLevels = map[string]gitlab.AccessLevelValue{
+	"no-one":     gitlab.NoPermissions,
+	"developer":  gitlab.DeveloperPermissions,
+	"maintainer": gitlab.MasterPermissions,
+	"admin":      gitlab.AccessLevelValue(60),
+}
+
+const defaultProtectionLevel = "maintainer"
+
+//protectionLevelName return the forjj protection level name of a gitlab access level.
+func protectionLevelName(level gitlab.AccessLevelValue) string {
+	for name, value := range protectionLevels {
+		if value == level {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d", level)
+}
+
+//ProtectionStruct protected branch or tag managed in gitlab. Identified in gitlab by its name pattern.
+type ProtectionStruct struct {
+	Push              string   // push (branch) or create (tag) access level
+	Merge             string   `yaml:",omitempty"` // branch only
+	Unprotect         string   `yaml:",omitempty"` // branch only
+	Users             []string `yaml:",omitempty"`
+	Groups            []string `yaml:",omitempty"`
+	CodeOwnerApproval bool     `yaml:"code-owner-approval,omitempty"` // branch only
+}
+
+//gitlabAccess protection access read from gitlab. A role, a user or a group.
+type gitlabAccess struct {
+	AccessLevel gitlab.AccessLevelValue `json:"access_level"`
+	UserID      int                     `json:"user_id"`
+	GroupID     int                     `json:"group_id"`
+}
+
+//gitlabProtection protected branch or tag read from gitlab
+type gitlabProtection struct {
+	Name                      string          `json:"name"`
+	PushAccessLevels          []*gitlabAccess `json:"push_access_levels"`
+	MergeAccessLevels         []*gitlabAccess `json:"merge_access_levels"`
+	UnprotectAccessLevels     []*gitlabAccess `json:"unprotect_access_levels"`
+	CreateAccessLevels        []*gitlabAccess `json:"create_access_levels"` // tags
+	CodeOwnerApprovalRequired bool            `json:"code_owner_approval_required"`
+}
+
+//allowedAccess user or group allowed by a protection
+type allowedAccess struct {
+	UserID  *int `json:"user_id,omitempty"`
+	GroupID *int `json:"group_id,omitempty"`
+}
+
+//protectionOptions protected branch or tag attributes sent to gitlab
+type protectionOptions struct {
+	Name                      *string          `json:"name"`
+	PushAccessLevel           *int             `json:"push_access_level,omitempty"`
+	MergeAccessLevel          *int             `json:"merge_access_level,omitempty"`
+	UnprotectAccessLevel      *int             `json:"unprotect_access_level,omitempty"`
+	CreateAccessLevel         *int             `json:"create_access_level,omitempty"`
+	AllowedToPush             []*allowedAccess `json:"allowed_to_push,omitempty"`
+	AllowedToMerge            []*allowedAccess `json:"allowed_to_merge,omitempty"`
+	AllowedToCreate           []*allowedAccess `json:"allowed_to_create,omitempty"`
+	CodeOwnerApprovalRequired *bool            `json:"code_owner_approval_required,omitempty"`
+}
+
+//isValid verify protection name, kind, pattern and access levels
+func (p *ProtectionsInstanceStruct) isValid(protectionName string) error {
+	if p.Name != protectionName {
+		return fmt.Errorf("Name must be equal to '%s'. But the protection name is set to '%s'.", protectionName, p.Name)
+	}
+	if p.Pattern == "" {
+		return fmt.Errorf("Pattern is empty.")
+	}
+	if p.Kind != "" && p.Kind != "branch" && p.Kind != "tag" {
+		return fmt.Errorf("kind must be 'branch' or 'tag'. Got '%s'.", p.Kind)
+	}
+	for _, level := range []string{p.Push, p.Merge, p.Unprotect} {
+		if _, found := protectionLevels[level]; level != "" && !found {
+			return fmt.Errorf("Unknown access level '%s'. Valid levels are no-one, developer, maintainer or admin.", level)
+		}
+	}
+	if p.Unprotect == "no-one" {
+		return fmt.Errorf("Unprotect access level can't be 'no-one'.")
+	}
+	return nil
+}
+
+//set protection from the forjj protection. Merge, Unprotect and CodeOwnerApproval are ignored for tags.
+func (p *ProtectionStruct) set(protection *ProtectionsInstanceStruct) *ProtectionStruct {
+	if p == nil {
+		p = new(ProtectionStruct)
+	}
+	level := func(name string) string {
+		if name == "" {
+			return defaultProtectionLevel
+		}
+		return name
+	}
+
+	p.Push = level(protection.Push)
+	p.Users = splitList(protection.Users)
+	p.Groups = splitList(protection.Groups)
+	if protection.Kind == "tag" {
+		return p
+	}
+	p.Merge = level(protection.Merge)
+	p.Unprotect = level(protection.Unprotect)
+	p.CodeOwnerApproval = (protection.CodeOwnerApproval == "true")
+	return p
+}
+
+//SetProjectProtections attach protections listing the project in repos. Invalid protections are ignored.
+func (gls *GitlabPlugin) SetProjectProtections(name string, project *RepoInstanceStruct, protections map[string]ProtectionsInstanceStruct, ret *goforjj.PluginData) {
+	pjt, found := gls.gitlabDeploy.Projects[name]
+	if !found {
+		return
+	}
+
+	pjt.ProtectionsManagement = project.ProtectionsManagement
+	if pjt.ProtectionsManagement != "manage" {
+		pjt.ProtectionsManagement = "sync"
+	}
+
+	pjt.ProtectedBranches = nil
+	pjt.ProtectedTags = nil
+	for protectionName, protection := range protections {
+		if !inList(name, splitList(protection.Repos)) {
+			continue
+		}
+		if err := protection.isValid(protectionName); err != nil {
+			log.Printf(ret.StatusAdd("Warning!!! Invalid protection '%s' requested. Ignored. %s", protectionName, err))
+			continue
+		}
+
+		protectionData := ProtectionStruct{}
+		protectionData.set(&protection)
+		if protection.Kind == "tag" {
+			if pjt.ProtectedTags == nil {
+				pjt.ProtectedTags = make(map[string]ProtectionStruct)
+			}
+			pjt.ProtectedTags[protection.Pattern] = protectionData
+			continue
+		}
+		if pjt.ProtectedBranches == nil {
+			pjt.ProtectedBranches = make(map[string]ProtectionStruct)
+		}
+		pjt.ProtectedBranches[protection.Pattern] = protectionData
+	}
+
+	gls.gitlabDeploy.Projects[name] = pjt
+}
+
+//allowed return users and groups allowed by the protection by gitlab ID.
+func (gls *GitlabPlugin) allowed(p *ProtectionStruct) (users, groups map[int]string, err error) {
+	users = make(map[int]string)
+	groups = make(map[int]string)
+	for _, name := range p.Users {
+		id, e := gls.userID(name)
+		if e != nil {
+			return nil, nil, e
+		}
+		users[id] = name
+	}
+	for _, name := range p.Groups {
+		id, e := gls.groupID(name)
+		if e != nil {
+			return nil, nil, e
+		}
+		groups[id] = name
+	}
+	return
+}
+
+//accessSummary return the role access level, users and groups IDs of a gitlab protection access list.
+func accessSummary(list []*gitlabAccess) (level gitlab.AccessLevelValue, users, groups map[int]bool) {
+	users = make(map[int]bool)
+	groups = make(map[int]bool)
+	for _, access := range list {
+		switch {
+		case access.UserID != 0:
+			users[access.UserID] = true
+		case access.GroupID != 0:
+			groups[access.GroupID] = true
+		default:
+			level = access.AccessLevel
+		}
+	}
+	return
+}
+
+//sameIDs return true if both lists contain the same IDs.
+func sameIDs(live map[int]bool, declared map[int]string) bool {
+	if len(live) != len(declared) {
+		return false
+	}
+	for id := range declared {
+		if !live[id] {
+			return false
+		}
+	}
+	return true
+}
+
+//names return the sorted names of declared IDs.
+func names(declared map[int]string) string {
+	list := make([]string, 0, len(declared))
+	for _, name := range declared {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
+//liveNames return the sorted names of live IDs. IDs not declared are unknown by name and given as #<id>.
+func liveNames(live map[int]bool, declared map[int]string) string {
+	list := make([]string, 0, len(live))
+	for id := range live {
+		if name, found := declared[id]; found {
+			list = append(list, name)
+		} else {
+			list = append(list, fmt.Sprintf("#%d", id))
+		}
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
+//changes return protection attributes changed in gitlab. users and groups are the declared allowed ones.
+func (p *ProtectionStruct) changes(live *gitlabProtection, tag bool, users, groups map[int]string) (changes []attributeChange) {
+	compare := func(attribute string, list []*gitlabAccess, levelName string) {
+		level, liveUsers, liveGroups := accessSummary(list)
+		if level != protectionLevels[levelName] {
+			changes = append(changes, changed(attribute+" access level", protectionLevelName(level), levelName))
+		}
+		if !sameIDs(liveUsers, users) {
+			changes = append(changes, changed(attribute+" users", liveNames(liveUsers, users), names(users)))
+		}
+		if !sameIDs(liveGroups, groups) {
+			changes = append(changes, changed(attribute+" groups", liveNames(liveGroups, groups), names(groups)))
+		}
+	}
+
+	if tag {
+		compare("create", live.CreateAccessLevels, p.Push)
+		return
+	}
+	compare("push", live.PushAccessLevels, p.Push)
+	compare("merge", live.MergeAccessLevels, p.Merge)
+	//unprotect access levels are not reported by all gitlab editions.
+	if len(live.UnprotectAccessLevels) > 0 {
+		if level, _, _ := accessSummary(live.UnprotectAccessLevels); level != protectionLevels[p.Unprotect] {
+			changes = append(changes, changed("unprotect access level", protectionLevelName(level), p.Unprotect))
+		}
+	}
+	if live.CodeOwnerApprovalRequired != p.CodeOwnerApproval {
+		changes = append(changes, changed("code owner approval", live.CodeOwnerApprovalRequired, p.CodeOwnerApproval))
+	}
+	return
+}
+
+//summary return the protection access levels description.
+func (p *ProtectionStruct) summary(tag bool) string {
+	if tag {
+		return fmt.Sprintf("create '%s'", p.Push)
+	}
+	return fmt.Sprintf("push '%s', merge '%s'", p.Push, p.Merge)
+}
+
+//options return gitlab protected branch or tag options.
+func (p *ProtectionStruct) options(pattern string, tag bool, users, groups map[int]string) *protectionOptions {
+	level := func(name string) *int {
+		if name == "" {
+			return nil
+		}
+		return gitlab.Int(int(protectionLevels[name]))
+	}
+
+	var allowed []*allowedAccess
+	for id := range users {
+		allowed = append(allowed, &allowedAccess{UserID: gitlab.Int(id)})
+	}
+	for id := range groups {
+		allowed = append(allowed, &allowedAccess{GroupID: gitlab.Int(id)})
+	}
+
+	options := &protectionOptions{Name: &pattern}
+	if tag {
+		options.CreateAccessLevel = level(p.Push)
+		options.AllowedToCreate = allowed
+		return options
+	}
+	options.PushAccessLevel = level(p.Push)
+	options.MergeAccessLevel = level(p.Merge)
+	options.UnprotectAccessLevel = level(p.Unprotect)
+	options.AllowedToPush = allowed
+	options.AllowedToMerge = allowed
+	if p.CodeOwnerApproval {
+		options.CodeOwnerApprovalRequired = gitlab.Bool(true)
+	}
+	return options
+}
+
+//options return options to protect again the gitlab protection (restore).
+func (live *gitlabProtection) options(tag bool) *protectionOptions {
+	access := func(list []*gitlabAccess) (level *int, allowed []*allowedAccess) {
+		for _, a := range list {
+			switch {
+			case a.UserID != 0:
+				allowed = append(allowed, &allowedAccess{UserID: gitlab.Int(a.UserID)})
+			case a.GroupID != 0:
+				allowed = append(allowed, &allowedAccess{GroupID: gitlab.Int(a.GroupID)})
+			default:
+				level = gitlab.Int(int(a.AccessLevel))
+			}
+		}
+		return
+	}
+
+	options := &protectionOptions{Name: gitlab.String(live.Name)}
+	if tag {
+		options.CreateAccessLevel, options.AllowedToCreate = access(live.CreateAccessLevels)
+		return options
+	}
+	options.PushAccessLevel, options.AllowedToPush = access(live.PushAccessLevels)
+	options.MergeAccessLevel, options.AllowedToMerge = access(live.MergeAccessLevels)
+	if len(live.UnprotectAccessLevels) > 0 {
+		options.UnprotectAccessLevel, _ = access(live.UnprotectAccessLevels)
+	}
+	if live.CodeOwnerApprovalRequired {
+		options.CodeOwnerApprovalRequired = gitlab.Bool(true)
+	}
+	return options
+}
+
+//listProtections return protected branches or tags of protectionsPath (projects/<id>/protected_branches or
+// projects/<id>/protected_tags) by name.
+func (gls *GitlabPlugin) listProtections(protectionsPath string) (protections map[string]*gitlabProtection, err error) {
+	var list []*gitlabProtection
+	options := &gitlab.ListOptions{PerPage: 100}
+	protections = make(map[string]*gitlabProtection)
+	for {
+		list = nil
+		req, e := gls.Client.NewRequest("GET", protectionsPath, options, nil)
+		if e != nil {
+			return nil, e
+		}
+		resp, e := gls.Client.Do(req, &list)
+		if e != nil {
+			return nil, e
+		}
+		for _, protection := range list {
+			protections[protection.Name] = protection
+		}
+		if resp.NextPage == 0 {
+			return
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+//ensureProtected create, update or delete the project protected branches or tags (resource protected_branches or
+// protected_tags). gitlab can't update a protection, so it is unprotected and protected again. The previous protection
+// is restored if the new one is refused.
+// With 'sync' policy, undeclared protections are deleted, except the gitlab default branch protection.
+// project is nil if not created yet (plan mode).
+func (r *ProjectStruct) ensureProtected(gls *GitlabPlugin, project *gitlab.Project, resource string, protections map[string]ProtectionStruct, ret *goforjj.PluginData) error {
+	tag := (resource == "protected_tags")
+	kind := "protected branch"
+	if tag {
+		kind = "protected tag"
+	}
+
+	current := make(map[string]*gitlabProtection)
+	protectionsPath := ""
+	if project != nil {
+		protectionsPath = fmt.Sprintf("projects/%d/%s", project.ID, resource)
+		list, err := gls.listProtections(protectionsPath)
+		if err != nil {
+			ret.Errorf("Unable to get '%s' %ss. %s", r.Name, kind, err)
+			return err
+		}
+		current = list
+	}
+
+	for pattern, protection := range protections {
+		users, groups, err := gls.allowed(&protection)
+		if err != nil {
+			ret.Errorf("Unable to protect '%s' %s '%s'. %s", r.Name, kind, pattern, err)
+			return err
+		}
+
+		live, found := current[pattern]
+		if found {
+			changes := protection.changes(live, tag, users, groups)
+			if len(changes) == 0 || gls.plannedChanges(kind, r.Name+"/"+pattern, "update", changes) {
+				continue
+			}
+		} else if gls.planned(kind, r.Name+"/"+pattern, "create", protection.summary(tag)) {
+			continue
+		}
+
+		if found {
+			if err := gls.apiRequest("DELETE", protectionsPath+"/"+url.QueryEscape(pattern), nil, nil); err != nil {
+				ret.Errorf("Unable to update '%s' %s '%s'. %s", r.Name, kind, pattern, err)
+				return err
+			}
+		}
+		if err := gls.apiRequest("POST", protectionsPath, protection.options(pattern, tag, users, groups), nil); err != nil {
+			if !found {
+				ret.Errorf("Unable to protect '%s' %s '%s'. %s", r.Name, kind, pattern, err)
+				return err
+			}
+			//never left unprotected
+			if e := gls.apiRequest("POST", protectionsPath, live.options(tag), nil); e != nil {
+				ret.Errorf("Unable to update '%s' %s '%s'. %s. Unable to restore the previous protection. %s", r.Name, kind, pattern, err, e)
+				return err
+			}
+			ret.Errorf("Unable to update '%s' %s '%s'. %s. Previous protection restored.", r.Name, kind, pattern, err)
+			return err
+		}
+		if found {
+			log.Printf(ret.StatusAdd("Repo '%s': %s '%s' updated", r.Name, kind, pattern))
+		} else {
+			log.Printf(ret.StatusAdd("Repo '%s': %s '%s' created", r.Name, kind, pattern))
+		}
+	}
+
+	if r.ProtectionsManagement != "sync" {
+		return nil
+	}
+
+	for pattern := range current {
+		if _, declared := protections[pattern]; declared {
+			continue
+		}
+		if !tag && pattern == project.DefaultBranch {
+			//gitlab default branch protection kept if not declared.
+			continue
+		}
+		if gls.planned(kind, r.Name+"/"+pattern, "delete", "") {
+			continue
+		}
+		if err := gls.apiRequest("DELETE", protectionsPath+"/"+url.QueryEscape(pattern), nil, nil); err != nil {
+			ret.Errorf("Unable to unprotect '%s' %s '%s'. %s", r.Name, kind, pattern, err)
+			return err
+		}
+		log.Printf(ret.StatusAdd("Repo '%s': %s '%s' deleted", r.Name, kind, pattern))
+	}
+	return nil
+}
+
+//ensureProtections maintain project protected branches and tags.
+func (r *ProjectStruct) ensureProtections(gls *GitlabPlugin, project *gitlab.Project, ret *goforjj.PluginData) error {
+	if err := r.ensureProtected(gls, project, "protected_branches", r.ProtectedBranches, ret); err != nil {
+		return err
+	}
+	return r.ensureProtected(gls, project, "protected_tags", r.ProtectedTags, ret)
+}
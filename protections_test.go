@@ -0,0 +1,148 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestAccessSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		list   []*gitlabAccess
+		level  gitlab.AccessLevelValue
+		users  map[int]bool
+		groups map[int]bool
+	}{
+		{"empty", nil, gitlab.NoPermissions, map[int]bool{}, map[int]bool{}},
+		{"role", []*gitlabAccess{{AccessLevel: gitlab.DeveloperPermissions}}, gitlab.DeveloperPermissions, map[int]bool{}, map[int]bool{}},
+		{"role, users and groups", []*gitlabAccess{
+			{AccessLevel: gitlab.MasterPermissions},
+			{AccessLevel: gitlab.MasterPermissions, UserID: 3},
+			{UserID: 5},
+			{AccessLevel: gitlab.DeveloperPermissions, GroupID: 7},
+		}, gitlab.MasterPermissions, map[int]bool{3: true, 5: true}, map[int]bool{7: true}},
+		{"users only", []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions, UserID: 3}}, gitlab.NoPermissions, map[int]bool{3: true}, map[int]bool{}},
+	}
+
+	for _, test := range tests {
+		level, users, groups := accessSummary(test.list)
+		if level != test.level || !reflect.DeepEqual(users, test.users) || !reflect.DeepEqual(groups, test.groups) {
+			t.Errorf("accessSummary(%s): %d, %v, %v, expected %d, %v, %v", test.name, level, users, groups, test.level, test.users, test.groups)
+		}
+	}
+}
+
+func TestProtectionChanges(t *testing.T) {
+	role := func(level gitlab.AccessLevelValue) []*gitlabAccess {
+		return []*gitlabAccess{{AccessLevel: level}}
+	}
+	branch := ProtectionStruct{Push: "no-one", Merge: "developer", Unprotect: "maintainer"}
+
+	tests := []struct {
+		name       string
+		protection ProtectionStruct
+		live       gitlabProtection
+		tag        bool
+		users      map[int]string
+		groups     map[int]string
+		changes    []string
+	}{
+		{"branch unchanged", branch, gitlabProtection{
+			PushAccessLevels:      role(gitlab.NoPermissions),
+			MergeAccessLevels:     role(gitlab.DeveloperPermissions),
+			UnprotectAccessLevels: role(gitlab.MasterPermissions),
+		}, false, nil, nil, nil},
+		{"branch without unprotect access levels", branch, gitlabProtection{
+			PushAccessLevels:  role(gitlab.NoPermissions),
+			MergeAccessLevels: role(gitlab.DeveloperPermissions),
+		}, false, nil, nil, nil},
+		{"branch levels changed", branch, gitlabProtection{
+			PushAccessLevels:          role(gitlab.DeveloperPermissions),
+			MergeAccessLevels:         role(gitlab.DeveloperPermissions),
+			UnprotectAccessLevels:     role(gitlab.DeveloperPermissions),
+			CodeOwnerApprovalRequired: true,
+		}, false, nil, nil, []string{
+			"push access level changed from 'developer' to 'no-one'",
+			"unprotect access level changed from 'developer' to 'maintainer'",
+			"code owner approval changed from 'true' to 'false'",
+		}},
+		{"branch users and groups", ProtectionStruct{Push: "maintainer", Merge: "maintainer", Unprotect: "maintainer"}, gitlabProtection{
+			PushAccessLevels:  []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}, {UserID: 3}, {UserID: 9}},
+			MergeAccessLevels: []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}, {UserID: 3}, {GroupID: 7}},
+		}, false, map[int]string{3: "alice"}, map[int]string{7: "acme/dev"}, []string{
+			"push users changed from '#9, alice' to 'alice'",
+			"push groups changed from '' to 'acme/dev'",
+		}},
+		{"tag unchanged", ProtectionStruct{Push: "maintainer"}, gitlabProtection{
+			CreateAccessLevels: []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}, {UserID: 3}},
+			PushAccessLevels:   role(gitlab.DeveloperPermissions),
+		}, true, map[int]string{3: "alice"}, nil, nil},
+		{"tag changed", ProtectionStruct{Push: "no-one"}, gitlabProtection{
+			CreateAccessLevels: []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}, {GroupID: 7}},
+		}, true, nil, nil, []string{
+			"create access level changed from 'maintainer' to 'no-one'",
+			"create groups changed from '#7' to ''",
+		}},
+	}
+
+	for _, test := range tests {
+		var changes []string
+		for _, change := range test.protection.changes(&test.live, test.tag, test.users, test.groups) {
+			changes = append(changes, change.String())
+		}
+		if !reflect.DeepEqual(changes, test.changes) {
+			t.Errorf("changes(%s): %q, expected %q", test.name, changes, test.changes)
+		}
+	}
+}
+
+func TestGitlabProtectionOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		live    gitlabProtection
+		tag     bool
+		options protectionOptions
+	}{
+		{"branch", gitlabProtection{
+			Name:                      "main",
+			PushAccessLevels:          []*gitlabAccess{{AccessLevel: gitlab.NoPermissions}, {UserID: 3}},
+			MergeAccessLevels:         []*gitlabAccess{{AccessLevel: gitlab.DeveloperPermissions}, {GroupID: 7}},
+			UnprotectAccessLevels:     []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}},
+			CodeOwnerApprovalRequired: true,
+		}, false, protectionOptions{
+			Name:                      gitlab.String("main"),
+			PushAccessLevel:           gitlab.Int(0),
+			MergeAccessLevel:          gitlab.Int(30),
+			UnprotectAccessLevel:      gitlab.Int(40),
+			AllowedToPush:             []*allowedAccess{{UserID: gitlab.Int(3)}},
+			AllowedToMerge:            []*allowedAccess{{GroupID: gitlab.Int(7)}},
+			CodeOwnerApprovalRequired: gitlab.Bool(true),
+		}},
+		{"branch without unprotect access levels", gitlabProtection{
+			Name:              "release/*",
+			PushAccessLevels:  []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}},
+			MergeAccessLevels: []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}},
+		}, false, protectionOptions{
+			Name:             gitlab.String("release/*"),
+			PushAccessLevel:  gitlab.Int(40),
+			MergeAccessLevel: gitlab.Int(40),
+		}},
+		{"tag", gitlabProtection{
+			Name:               "v*",
+			CreateAccessLevels: []*gitlabAccess{{AccessLevel: gitlab.MasterPermissions}, {UserID: 3}, {GroupID: 7}},
+			PushAccessLevels:   []*gitlabAccess{{AccessLevel: gitlab.DeveloperPermissions}},
+		}, true, protectionOptions{
+			Name:              gitlab.String("v*"),
+			CreateAccessLevel: gitlab.Int(40),
+			AllowedToCreate:   []*allowedAccess{{UserID: gitlab.Int(3)}, {GroupID: gitlab.Int(7)}},
+		}},
+	}
+
+	for _, test := range tests {
+		if options := test.live.options(test.tag); !reflect.DeepEqual(*options, test.options) {
+			t.Errorf("options(%s): %+v, expected %+v", test.name, *options, test.options)
+		}
+	}
+}
@@ -14,4 +14,5 @@ var routes = Routes{
 	Route{"Create", "POST", "/create", Create},
 	Route{"Update", "POST", "/update", Update},
 	Route{"Maintain", "POST", "/maintain", Maintain},
+	Route{"Drift", "POST", "/drift", Drift},
 }
@@ -6,6 +6,8 @@ package main
 import (
 	"fmt"
 	"github.com/forj-oss/goforjj"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
@@ -57,7 +59,7 @@ func addMaintainOptionValue(options map[string]goforjj.PluginOption, option, val
 	return opt
 }
 
-//SetProject set default remotes and branchConnect (TODO)
+//SetProject set default remotes and connect the project default branch
 func (gls *GitlabPlugin) SetProject(project *RepoInstanceStruct, isInfra, isDeployable bool) {
 	upstream := gls.DefineRepoUrls(project.Name)
 
@@ -70,54 +72,132 @@ func (gls *GitlabPlugin) SetProject(project *RepoInstanceStruct, isInfra, isDepl
 	pjt := ProjectStruct{}
 	pjt.set(project,
 				map[string]goforjj.PluginRepoRemoteUrl{"origin": upstream},
-				map[string]string{"master": "origin/master"},
+				nil,
 				isInfra,
 				isDeployable, owner)
+	pjt.connectBranch(pjt.defaultBranch())
+	if pjt.Visibility == "" {
+		pjt.Visibility = gls.app.Visibility
+	}
 	gls.gitlabDeploy.Projects[project.Name] = pjt
 }
 
-//updateYamlData TODO
-func (gls *GitlabPlugin) updateYamlData(req *UpdateReq, ret *goforjj.PluginData) (bool, error) {
+//updateYamlData rebuild deploy data from the Forjfile. Removed projects are marked or removed according to the
+// removed-projects-policy. Remotes of existing projects are kept.
+func (gls *GitlabPlugin) updateYamlData(req *UpdateReq, ret *goforjj.PluginData) error {
 	if gls.gitlabSource.Urls == nil {
-		return false, fmt.Errorf("Internal Error. Urls was not set")
+		return fmt.Errorf("Internal Error. Urls was not set")
 	}
 
-	if gls.gitlabDeploy.Projects == nil {
-		gls.gitlabDeploy.Projects = make(map[string]ProjectStruct)
-	}
+	previousProjects := gls.gitlabDeploy.Projects
+	gls.gitlabDeploy.Projects = make(map[string]ProjectStruct)
 
 	//In update, we simply rebuild Users and Team from Forjfile.
 	//No need to keep track of removed one
-	//gls.gitlabDeploy.Users = make(map[string]string)
-	//...
+	if err := gls.SetVisibility(); err != nil {
+		return err
+	}
+	if err := gls.SetRemovedProjectsPolicy(); err != nil {
+		return err
+	}
+	gls.SetUsers(req.Objects.User, ret)
+	gls.SetGroups(req.Objects.Group, ret)
+	gls.gitlabDeploy.NoProjectHooks = (gls.app.ProjectsWebhooksDisabled == "true")
+	if err := gls.SetGroupHooks(req.Objects.Webhooks, ret); err != nil {
+		return err
+	}
 
-	if gls.app.ProjectsDisabled == "true" {
+	gls.gitlabDeploy.NoProjects = (gls.app.ProjectsDisabled == "true")
+	if gls.gitlabDeploy.NoProjects {
 		log.Print("ProjectsDisabled is true. forjj_gitlab won't manage projects except the infra one.")
-		gls.gitlabDeploy.NoProjects = true
-	} else {
-		//Updating all from Forjfile repos
-		gls.gitlabDeploy.NoProjects = false
-		//OrgHooks
-
-		for name, pjt := range req.Objects.Repo{
-			if !pjt.isValid(name, ret){
-				continue
+	}
+
+	//Updating all from Forjfile repos
+	for name, pjt := range req.Objects.Repo{
+		isInfra := (name == gls.app.ForjjInfra)
+		if gls.gitlabDeploy.NoProjects && !isInfra {
+			continue
+		}
+		if !pjt.isValid(name, ret){
+			//kept unchanged, not seen as removed (nor renamed)
+			if previous, found := previousProjects[name]; found {
+				gls.gitlabDeploy.Projects[name] = previous
+			} else if previous, found := previousProjects[pjt.FormerName]; found {
+				gls.gitlabDeploy.Projects[pjt.FormerName] = previous
 			}
-			gls.SetProject(&pjt, (name == gls.app.ForjjInfra), pjt.Deployable == "true")
-			//hooks
+			continue
 		}
+		gls.SetProject(&pjt, isInfra, pjt.Deployable == "true")
+		previous, found := previousProjects[name]
+		renamed := false
+		if !found && pjt.FormerName != "" {
+			//renamed: the gitlab project is tracked from the former project
+			previous, found = previousProjects[pjt.FormerName]
+			renamed = found
+		}
+		if found {
+			project := gls.gitlabDeploy.Projects[name]
+			project.keepRemotes(previous, renamed)
+			project.keepRemovedMembers(previous)
+			gls.gitlabDeploy.Projects[name] = project
+		}
+		if err := gls.SetProjectHooks(name, &pjt, req.Objects.Webhooks, ret); err != nil {
+			return err
+		}
+		gls.SetProjectProtections(name, &pjt, req.Objects.Protections, ret)
+	}
 
-		//Disabling missing one
-		/*for name, pjt := range gls.gitlabDeploy.Projects {
-			if err := pjt.isValid(name); err != nil { TODO isValid and delete
-				delete(gls.gitlabDeploy.Projects, name)
-
-			}
-		}*/
+	//Projects removed from the Forjfile
+	policy := gls.gitlabDeploy.RemovedProjectsPolicy
+	renamed := make(map[string]bool)
+	for _, pjt := range gls.gitlabDeploy.Projects {
+		if pjt.FormerName != "" {
+			renamed[pjt.FormerName] = true
+		}
+	}
+	for name, pjt := range previousProjects {
+		if _, found := gls.gitlabDeploy.Projects[name]; found {
+			continue
+		}
+		if renamed[name] {
+			log.Printf(ret.StatusAdd("Project '%s' renamed in the Forjfile.", name))
+			continue
+		}
+		if gls.gitlabDeploy.NoProjects && !pjt.Infra {
+			log.Printf("Project '%s' not managed anymore.", name)
+			continue
+		}
+		if pjt.Imported {
+			//kept unchanged until declared in the Forjfile
+			gls.gitlabDeploy.Projects[name] = pjt
+			continue
+		}
+		if policy == "remove" {
+			log.Printf(ret.StatusAdd("Project '%s' removed from the Forjfile. Forgotten by forjj-gitlab.", name))
+			continue
+		}
+		if !pjt.Removed {
+			log.Printf(ret.StatusAdd("Project '%s' removed from the Forjfile. Marked as removed.", name))
+		}
+		pjt.Removed = true
+		gls.gitlabDeploy.Projects[name] = pjt
 	}
-	
 
-	//...
+	log.Printf("forjj-gitlab manages %d project(s).", len(gls.gitlabDeploy.Projects))
+
+	return nil
+}
 
-	return false, fmt.Errorf("TODO")
-}
\ No newline at end of file
+//deployChanged return true if deploy data differs from the deploy file.
+func (gls *GitlabPlugin) deployChanged() (bool, error) {
+	d, err := yaml.Marshal(&gls.gitlabDeploy)
+	if err != nil {
+		return false, fmt.Errorf("Unable to encode gitlab data in yaml. %s", err)
+	}
+
+	dBefore, err := ioutil.ReadFile(gls.deployFile)
+	if err != nil {
+		return true, nil
+	}
+	return string(d) != string(dBefore), nil
+}
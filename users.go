@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+
+	"github.com/forj-oss/goforjj"
+	"github.com/xanzy/go-gitlab"
+)
+
+//isValid verify user name and role
+func (u *UserInstanceStruct) isValid(userName string, ret *goforjj.PluginData) (valid bool) {
+	if u.Name == "" {
+		ret.Errorf("Invalid user '%s'. Name is empty.", userName)
+		return
+	}
+	if u.Name != userName {
+		ret.Errorf("Invalid user '%s'. Name must be equal to '%s'. But the user name is set to '%s'.", userName, userName, u.Name)
+		return
+	}
+	if _, found := accessLevels[u.Role]; u.Role != "" && !found {
+		ret.Errorf("Invalid user '%s'. Unknown role '%s'. Valid roles are guest, reporter, developer, maintainer or owner.", userName, u.Role)
+		return
+	}
+	valid = true
+	return
+}
+
+//SetUsers set forge group members (name: role) from forjj users. Invalid users are ignored.
+func (gls *GitlabPlugin) SetUsers(users map[string]UserInstanceStruct, ret *goforjj.PluginData) {
+	gls.gitlabDeploy.Users = make(map[string]string)
+
+	for name, user := range users {
+		if !user.isValid(name, ret) {
+			ret.StatusAdd("Warning!!! Invalid user '%s' requested. Ignored.", name)
+			continue
+		}
+		role := user.Role
+		if role == "" {
+			role = defaultAccessLevel
+		}
+		gls.gitlabDeploy.Users[name] = role
+	}
+	log.Printf("forjj-gitlab manages %d user(s).", len(gls.gitlabDeploy.Users))
+}
+
+//ensureUsers maintain forge group members from Users.
+// Undeclared members are removed only with force on a new forge.
+func (gls *GitlabPlugin) ensureUsers(ret *goforjj.PluginData) error {
+	if len(gls.gitlabDeploy.Users) == 0 {
+		log.Printf(ret.StatusAdd("No users declared. '%s' group members are not managed.", gls.gitlabDeploy.Group))
+		return nil
+	}
+
+	var group *gitlab.Group
+	if gls.gitlabDeploy.GroupId != 0 {
+		group = &gitlab.Group{ID: gls.gitlabDeploy.GroupId, FullPath: gls.gitlabDeploy.Group}
+	}
+	return gls.ensureGroupMembers(group, gls.gitlabDeploy.Group, gls.gitlabDeploy.Users, gls.force && gls.newForge, ret)
+}
@@ -3,6 +3,7 @@ package main
 import(
 	"os"
 	"fmt"
+	"strings"
 
 	"github.com/forj-oss/goforjj"
 	"golang.org/x/sys/unix"
@@ -53,3 +54,23 @@ func (gls *GitlabPlugin) verifyReqFails(ret *goforjj.PluginData, check map[strin
 
 	return false
 }
+
+//splitList return the list of values separated by comma. Empty values are ignored.
+func splitList(list string) (values []string) {
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return
+}
+
+//inList return true if value is in the list.
+func inList(value string, list []string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+//visibilityLevels gitlab visibilities from the most restricted to the most open
+var visibilityLevels = map[gitlab.VisibilityValue]int{
+	gitlab.PrivateVisibility:  0,
+	gitlab.InternalVisibility: 1,
+	gitlab.PublicVisibility:   2,
+}
+
+//isValidVisibility return an error if the visibility is set and is not private, internal or public.
+func isValidVisibility(visibility string) error {
+	if _, found := visibilityLevels[gitlab.VisibilityValue(visibility)]; visibility != "" && !found {
+		return fmt.Errorf("Unknown visibility '%s'. Valid visibilities are private, internal or public.", visibility)
+	}
+	return nil
+}
+
+//checkVisibility return an error if the visibility is more open than the parent group visibility. gitlab rejects it.
+func checkVisibility(name string, visibility gitlab.VisibilityValue, parent *gitlab.Group) error {
+	if visibility == "" || parent == nil || parent.Visibility == nil {
+		return nil
+	}
+	if visibilityLevels[visibility] > visibilityLevels[*parent.Visibility] {
+		return fmt.Errorf("'%s' visibility can't be '%s' because its group '%s' visibility is '%s'. Use a visibility not more open than the group one.", name, visibility, parent.FullPath, *parent.Visibility)
+	}
+	return nil
+}
+
+//SetVisibility validate app visibilities and set the groups visibility.
+func (gls *GitlabPlugin) SetVisibility() error {
+	if err := isValidVisibility(gls.app.Visibility); err != nil {
+		return fmt.Errorf("Invalid app visibility. %s", err)
+	}
+	if err := isValidVisibility(gls.app.GroupVisibility); err != nil {
+		return fmt.Errorf("Invalid app group-visibility. %s", err)
+	}
+	gls.gitlabDeploy.GroupVisibility = gls.app.GroupVisibility
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestCheckVisibility(t *testing.T) {
+	group := func(visibility gitlab.VisibilityValue) *gitlab.Group {
+		return &gitlab.Group{FullPath: "acme", Visibility: gitlab.Visibility(visibility)}
+	}
+	tests := []struct {
+		visibility gitlab.VisibilityValue
+		parent     *gitlab.Group
+		fails      bool
+	}{
+		{"", group(gitlab.PrivateVisibility), false},
+		{gitlab.PublicVisibility, nil, false},
+		{gitlab.PublicVisibility, &gitlab.Group{FullPath: "acme"}, false},
+		{gitlab.PrivateVisibility, group(gitlab.PrivateVisibility), false},
+		{gitlab.InternalVisibility, group(gitlab.PublicVisibility), false},
+		{gitlab.PublicVisibility, group(gitlab.PublicVisibility), false},
+		{gitlab.InternalVisibility, group(gitlab.PrivateVisibility), true},
+		{gitlab.PublicVisibility, group(gitlab.InternalVisibility), true},
+	}
+
+	for _, test := range tests {
+		err := checkVisibility("app", test.visibility, test.parent)
+		if test.fails && err == nil {
+			t.Errorf("checkVisibility(%q, %v): error expected", test.visibility, test.parent)
+		}
+		if !test.fails && err != nil {
+			t.Errorf("checkVisibility(%q, %v): unexpected error %s", test.visibility, test.parent, err)
+		}
+	}
+}